@@ -30,12 +30,7 @@ func Run() {
 	log.Infof("log level set to: %s", cfg.Logger.OutputLevel)
 
 	ctx := signals.Context()
-	app, err := app.NewApp(ctx, app.Options{
-		AppPort:      cfg.AppPort,
-		GpcProjectId: cfg.GpcProjectId,
-		RedisHost:    cfg.RedisHost,
-		RedisPort:    cfg.RedisPort,
-	})
+	app, err := app.NewApp(ctx, appOptions(cfg))
 	if err != nil {
 		log.Fatalf("error while creating translator: %v", err)
 	}
@@ -49,3 +44,40 @@ func Run() {
 
 	log.Info("translator shut down gracefully")
 }
+
+// appOptions maps the configuration to the options of the app.
+func appOptions(cfg *config.Config) app.Options {
+	return app.Options{
+		AppPort:                   cfg.AppPort,
+		GrpcPort:                  cfg.GrpcPort,
+		ShutdownDelay:             cfg.ShutdownDelay,
+		MetricsEnabled:            cfg.MetricsEnabled,
+		MetricsPort:               cfg.MetricsPort,
+		AdminToken:                cfg.AdminToken,
+		GpcProjectId:              cfg.GpcProjectId,
+		Model:                     cfg.Model,
+		UpstreamTimeout:           cfg.UpstreamTimeout,
+		CacheBackend:              cfg.CacheBackend,
+		Redis:                     cfg.Redis,
+		Namespace:                 cfg.Namespace,
+		CacheTTL:                  cfg.CacheTTL,
+		CachePairTTLs:             cfg.CachePairTTLs,
+		CacheSlidingExpiration:    cfg.CacheSlidingExpiration,
+		CacheMaxEntrySize:         cfg.CacheMaxEntrySize,
+		CacheMemoryMaxBytes:       cfg.CacheMemoryMaxBytes,
+		CacheCompressionThreshold: cfg.CacheCompressionThreshold,
+		CacheDiskPath:             cfg.CacheDiskPath,
+		CacheDiskCompaction:       cfg.CacheDiskCompaction,
+		CacheBreaker:              cfg.CacheBreaker,
+		CacheLock:                 cfg.CacheLock,
+		LocalCacheEnabled:         cfg.LocalCacheEnabled,
+		LocalCacheMaxBytes:        cfg.LocalCacheMaxBytes,
+		LocalCacheTTL:             cfg.LocalCacheTTL,
+		StatsFlushInterval:        cfg.StatsFlushInterval,
+		StatsSummaryInterval:      cfg.StatsSummaryInterval,
+		Redaction:                 cfg.Redaction,
+		Policy:                    cfg.Policy,
+		Normalizer:                cfg.Normalizer,
+		Warmup:                    cfg.Warmup,
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"github.com/dennishilgert/cloud-computing-2/cmd/config"
+	"github.com/dennishilgert/cloud-computing-2/internal/app"
+	"github.com/dennishilgert/cloud-computing-2/pkg/signals"
+	"github.com/spf13/pflag"
+)
+
+// Warmup pre-populates the cache with the translations of a phrase file and
+// exits once all phrases have been translated. The flags override the
+// configured warm-up options.
+func Warmup(args []string) {
+	flags := pflag.NewFlagSet("warmup-cache", pflag.ExitOnError)
+	file := flags.StringP("file", "f", "", "phrase file in text, CSV or JSONL format")
+	sourceLang := flags.String("source", "", "language of the phrases")
+	targetLangs := flags.StringSlice("target", nil, "languages to translate the phrases into")
+	concurrency := flags.Int("concurrency", 0, "amount of translations running in parallel")
+	maxChars := flags.Int("max-chars", -1, "maximum characters sent upstream, 0 for no limit")
+	flags.Parse(args)
+
+	cfg := config.MustLoad()
+	opts := appOptions(cfg)
+	if *file != "" {
+		opts.Warmup.File = *file
+	}
+	if *sourceLang != "" {
+		opts.Warmup.SourceLang = *sourceLang
+	}
+	if len(*targetLangs) > 0 {
+		opts.Warmup.TargetLangs = *targetLangs
+	}
+	if *concurrency > 0 {
+		opts.Warmup.Concurrency = *concurrency
+	}
+	if *maxChars >= 0 {
+		opts.Warmup.MaxChars = *maxChars
+	}
+
+	if opts.Warmup.File == "" {
+		log.Fatal("the phrase file must be set with --file or WARMUP_FILE")
+	}
+
+	ctx := signals.Context()
+	app, err := app.NewApp(ctx, opts)
+	if err != nil {
+		log.Fatalf("error while creating translator: %v", err)
+	}
+
+	progress, err := app.Warm(ctx)
+	if err != nil {
+		log.Fatalf("cache warm-up failed: %v", err)
+	}
+	if progress.Failed > 0 {
+		log.Warnf("%d translations failed during the warm-up", progress.Failed)
+	}
+	log.Info("cache warm-up finished")
+}
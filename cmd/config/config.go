@@ -1,18 +1,54 @@
 package config
 
 import (
+	"time"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/redact"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/warmup"
 	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 var log = logger.NewLogger("app.config")
 
 type Config struct {
-	AppPort      int
-	GpcProjectId string
-	RedisHost    string
-	RedisPort    int
-	Logger       logger.Options
+	AppPort                   int
+	GrpcPort                  int
+	ShutdownDelay             time.Duration
+	MetricsEnabled            bool
+	MetricsPort               int
+	AdminToken                string
+	GpcProjectId              string
+	Model                     string
+	UpstreamTimeout           time.Duration
+	CacheBackend              string
+	Redis                     cache.RedisOptions
+	Namespace                 string
+	CacheTTL                  time.Duration
+	CachePairTTLs             []string
+	CacheSlidingExpiration    bool
+	CacheMaxEntrySize         int
+	CacheMemoryMaxBytes       int
+	CacheCompressionThreshold int
+	CacheDiskPath             string
+	CacheDiskCompaction       time.Duration
+	CacheBreaker              cache.BreakerOptions
+	CacheLock                 cache.LockOptions
+	LocalCacheEnabled         bool
+	LocalCacheMaxBytes        int
+	LocalCacheTTL             time.Duration
+	StatsFlushInterval        time.Duration
+	StatsSummaryInterval      time.Duration
+	Redaction                 redact.Options
+	Policy                    policy.Options
+	Normalizer                normalize.Options
+	Warmup                    warmup.Options
+	Logger                    logger.Options
 }
 
 func Load() (*Config, error) {
@@ -27,9 +63,63 @@ func Load() (*Config, error) {
 	loadOrDefault("Logger.OutputLevel", "LOG_LEVEL", logger.DefaultOptions().OutputLevel)
 
 	loadOrDefault("AppPort", "APP_PORT", 80)
+	loadOrDefault("GrpcPort", "GRPC_PORT", 9090)
+	loadOrDefault("ShutdownDelay", "SHUTDOWN_DELAY", 5*time.Second)
+	loadOrDefault("MetricsEnabled", "METRICS_ENABLED", true)
+	loadOrDefault("MetricsPort", "METRICS_PORT", 0)
+	loadOrDefault("AdminToken", "ADMIN_TOKEN", "")
 	loadOrDefault("GpcProjectId", "GOOGLE_CLOUD_PROJECT_ID", nil)
-	loadOrDefault("RedisHost", "REDIS_HOST", nil)
-	loadOrDefault("RedisPort", "REDIS_PORT", 6379)
+	loadOrDefault("Model", "TRANSLATE_MODEL", translate.DefaultModel)
+	loadOrDefault("UpstreamTimeout", "TRANSLATE_TIMEOUT", 30*time.Second)
+	loadOrDefault("CacheBackend", "CACHE_BACKEND", cache.BackendRedis)
+	loadRedisOptions()
+	loadOrDefault("Namespace", "CACHE_NAMESPACE", "translator")
+	loadOrDefault("CacheTTL", "CACHE_TTL", 30*24*time.Hour)
+	loadOrDefault("CachePairTTLs", "CACHE_PAIR_TTLS", []string{})
+	loadOrDefault("CacheSlidingExpiration", "CACHE_SLIDING_EXPIRATION", false)
+	loadOrDefault("CacheMaxEntrySize", "CACHE_MAX_ENTRY_SIZE", 64*1024)
+	loadOrDefault("CacheMemoryMaxBytes", "CACHE_MEMORY_MAX_BYTES", 256*1024*1024)
+	loadOrDefault("CacheCompressionThreshold", "CACHE_COMPRESSION_THRESHOLD", 1024)
+	loadOrDefault("CacheDiskPath", "CACHE_DISK_PATH", "data/translations.db")
+	loadOrDefault("CacheDiskCompaction", "CACHE_DISK_COMPACTION_INTERVAL", time.Hour)
+	loadOrDefault("CacheBreaker.Enabled", "CACHE_BREAKER_ENABLED", true)
+	loadOrDefault("CacheBreaker.FailureThreshold", "CACHE_BREAKER_FAILURE_THRESHOLD", 5)
+	loadOrDefault("CacheBreaker.OpenDuration", "CACHE_BREAKER_OPEN_DURATION", 10*time.Second)
+	loadOrDefault("CacheBreaker.OperationTimeout", "CACHE_OPERATION_TIMEOUT", 250*time.Millisecond)
+	loadOrDefault("CacheLock.Enabled", "CACHE_LOCK_ENABLED", true)
+	loadOrDefault("CacheLock.TTL", "CACHE_LOCK_TTL", 10*time.Second)
+	loadOrDefault("CacheLock.Wait", "CACHE_LOCK_WAIT", 3*time.Second)
+	loadOrDefault("CacheLock.PollInterval", "CACHE_LOCK_POLL_INTERVAL", 100*time.Millisecond)
+	loadOrDefault("LocalCacheEnabled", "LOCAL_CACHE_ENABLED", true)
+	loadOrDefault("LocalCacheMaxBytes", "LOCAL_CACHE_MAX_BYTES", 64*1024*1024)
+	loadOrDefault("LocalCacheTTL", "LOCAL_CACHE_TTL", time.Minute)
+	loadOrDefault("StatsFlushInterval", "STATS_FLUSH_INTERVAL", 10*time.Second)
+	loadOrDefault("StatsSummaryInterval", "STATS_SUMMARY_INTERVAL", 15*time.Minute)
+
+	loadOrDefault("Redaction.Enabled", "REDACT_ENABLED", true)
+	loadOrDefault("Redaction.Entities", "REDACT_ENTITIES", []string{redact.EntityEmail, redact.EntityPhone, redact.EntityIban, redact.EntityCreditCard})
+	loadOrDefault("Redaction.EmailPattern", "REDACT_EMAIL_PATTERN", "")
+	loadOrDefault("Redaction.PhonePattern", "REDACT_PHONE_PATTERN", "")
+	loadOrDefault("Redaction.IbanPattern", "REDACT_IBAN_PATTERN", "")
+	loadOrDefault("Redaction.CreditCardPattern", "REDACT_CREDIT_CARD_PATTERN", "")
+
+	loadOrDefault("Policy.SourceAllow", "LANG_SOURCE_ALLOW", []string{})
+	loadOrDefault("Policy.SourceDeny", "LANG_SOURCE_DENY", []string{})
+	loadOrDefault("Policy.TargetAllow", "LANG_TARGET_ALLOW", []string{})
+	loadOrDefault("Policy.TargetDeny", "LANG_TARGET_DENY", []string{})
+	loadOrDefault("Policy.PairAllow", "LANG_PAIR_ALLOW", []string{})
+	loadOrDefault("Policy.PairDeny", "LANG_PAIR_DENY", []string{})
+
+	loadOrDefault("Normalizer.Unicode", "CACHE_KEY_UNICODE_NORMALIZATION", normalize.UnicodeNFC)
+	loadOrDefault("Normalizer.CollapseWhitespace", "CACHE_KEY_COLLAPSE_WHITESPACE", true)
+	loadOrDefault("Normalizer.CaseFoldMaxLength", "CACHE_KEY_CASE_FOLD_MAX_LENGTH", 0)
+	loadOrDefault("Normalizer.UnifyQuotes", "CACHE_KEY_UNIFY_QUOTES", true)
+
+	loadOrDefault("Warmup.File", "WARMUP_FILE", "")
+	loadOrDefault("Warmup.SourceLang", "WARMUP_SOURCE_LANG", "en")
+	loadOrDefault("Warmup.TargetLangs", "WARMUP_TARGET_LANGS", []string{})
+	loadOrDefault("Warmup.Concurrency", "WARMUP_CONCURRENCY", 4)
+	loadOrDefault("Warmup.MaxChars", "WARMUP_MAX_CHARS", 0)
 
 	// unmarshalling the Config struct
 	if err := viper.Unmarshal(&config); err != nil {
@@ -40,6 +130,60 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// MustLoad loads the configuration for a command line subcommand and applies
+// the logger options. The process exits if the configuration is invalid.
+func MustLoad() *Config {
+	// load environment variables on local installation
+	godotenv.Load()
+
+	cfg, err := Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = logger.ApplyOptionsToLoggers(&cfg.Logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// loadRedisOptions loads the connection settings of the redis backend. The
+// host is only required if redis is used as cache backend with a single node.
+func loadRedisOptions() {
+	loadOrDefault("Redis.SentinelMasterName", "REDIS_SENTINEL_MASTER_NAME", "")
+	loadOrDefault("Redis.SentinelAddrs", "REDIS_SENTINEL_ADDRS", []string{})
+	loadOrDefault("Redis.SentinelUsername", "REDIS_SENTINEL_USERNAME", "")
+	loadOrDefault("Redis.SentinelPassword", "REDIS_SENTINEL_PASSWORD", "")
+	loadOrDefault("Redis.ClusterAddrs", "REDIS_CLUSTER_ADDRS", []string{})
+
+	if viper.GetString("CacheBackend") == cache.BackendRedis &&
+		viper.GetString("Redis.SentinelMasterName") == "" &&
+		len(viper.GetStringSlice("Redis.ClusterAddrs")) == 0 {
+		loadOrDefault("Redis.Host", "REDIS_HOST", nil)
+	} else {
+		loadOrDefault("Redis.Host", "REDIS_HOST", "")
+	}
+	loadOrDefault("Redis.Port", "REDIS_PORT", 6379)
+	loadOrDefault("Redis.Username", "REDIS_USERNAME", "")
+	loadOrDefault("Redis.Password", "REDIS_PASSWORD", "")
+	loadOrDefault("Redis.DB", "REDIS_DB", 0)
+
+	loadOrDefault("Redis.TLSEnabled", "REDIS_TLS_ENABLED", false)
+	loadOrDefault("Redis.TLSCAFile", "REDIS_TLS_CA_FILE", "")
+	loadOrDefault("Redis.TLSCertFile", "REDIS_TLS_CERT_FILE", "")
+	loadOrDefault("Redis.TLSKeyFile", "REDIS_TLS_KEY_FILE", "")
+	loadOrDefault("Redis.TLSServerName", "REDIS_TLS_SERVER_NAME", "")
+	loadOrDefault("Redis.TLSInsecureSkipVerify", "REDIS_TLS_INSECURE_SKIP_VERIFY", false)
+
+	loadOrDefault("Redis.PoolSize", "REDIS_POOL_SIZE", 0)
+	loadOrDefault("Redis.MinIdleConns", "REDIS_MIN_IDLE_CONNS", 0)
+	loadOrDefault("Redis.DialTimeout", "REDIS_DIAL_TIMEOUT", 5*time.Second)
+	loadOrDefault("Redis.ReadTimeout", "REDIS_READ_TIMEOUT", 3*time.Second)
+	loadOrDefault("Redis.WriteTimeout", "REDIS_WRITE_TIMEOUT", 3*time.Second)
+	loadOrDefault("Redis.PoolTimeout", "REDIS_POOL_TIMEOUT", 4*time.Second)
+}
+
 func loadOrDefault(configVar string, envVar string, defaultVal any) {
 	if defaultVal != nil {
 		viper.SetDefault(configVar, defaultVal)
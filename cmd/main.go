@@ -1,7 +1,30 @@
 package main
 
-import "github.com/dennishilgert/cloud-computing-2/cmd/app"
+import (
+	"os"
+
+	"github.com/dennishilgert/cloud-computing-2/cmd/app"
+	"github.com/dennishilgert/cloud-computing-2/cmd/migrate"
+	"github.com/dennishilgert/cloud-computing-2/cmd/transfer"
+)
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate-cache":
+			migrate.Run(os.Args[2:])
+			return
+		case "export-cache":
+			transfer.Export(os.Args[2:])
+			return
+		case "import-cache":
+			transfer.Import(os.Args[2:])
+			return
+		case "warmup-cache":
+			app.Warmup(os.Args[2:])
+			return
+		}
+	}
+
 	app.Run()
 }
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"context"
+	"os"
+
+	"github.com/dennishilgert/cloud-computing-2/cmd/config"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"github.com/spf13/pflag"
+)
+
+var log = logger.NewLogger("app.migrate")
+
+// Run migrates the legacy md5 keys of the cache to the versioned key schema by
+// expiring or deleting them. Without --delete or --expire-after the legacy keys
+// are only counted.
+func Run(args []string) {
+	flags := pflag.NewFlagSet("migrate-cache", pflag.ExitOnError)
+	expireAfter := flags.Duration("expire-after", 0, "expire legacy keys after this duration")
+	deleteKeys := flags.Bool("delete", false, "delete legacy keys immediately")
+	dryRun := flags.Bool("dry-run", false, "only count the legacy keys without modifying them, the default without --delete or --expire-after")
+	sampleSize := flags.Int("sample", 10, "amount of legacy keys printed before modifying them")
+	flags.Parse(args)
+
+	if *deleteKeys && *expireAfter > 0 {
+		log.Fatal("--delete and --expire-after can not be combined")
+	}
+	if !*deleteKeys && *expireAfter <= 0 {
+		*dryRun = true
+	}
+
+	cfg := config.MustLoad()
+
+	if cfg.CacheBackend != cache.BackendRedis {
+		log.Infof("nothing to migrate for the %s cache backend", cfg.CacheBackend)
+		return
+	}
+
+	migrator, err := cache.NewMigrator(cache.Options{
+		Redis: cfg.Redis,
+	})
+	if err != nil {
+		log.Fatalf("failed to create migrator: %v", err)
+	}
+	defer migrator.Close()
+
+	// legacy keys are only recognized by their format, so a sample is printed
+	// before anything is changed to reveal keys of other applications that
+	// share the redis database
+	ctx := context.Background()
+	preview, err := migrator.Migrate(ctx, cache.MigrateOptions{
+		DryRun:     true,
+		SampleSize: *sampleSize,
+	})
+	if err != nil {
+		log.Errorf("failed to scan cache keys: %v", err)
+		os.Exit(1)
+	}
+	log.Infof("found %d legacy keys in %d keys", preview.Legacy, preview.Scanned)
+	for _, key := range preview.Sample {
+		log.Infof("legacy key: %s", key)
+	}
+
+	if *dryRun {
+		log.Info("dry run finished, run with --delete or --expire-after to migrate the legacy keys")
+		return
+	}
+	if preview.Legacy == 0 {
+		return
+	}
+
+	log.Infof("migrating legacy cache keys (expire after: %v, delete: %v)", *expireAfter, *deleteKeys)
+	result, err := migrator.Migrate(ctx, cache.MigrateOptions{
+		ExpireAfter: *expireAfter,
+	})
+	if err != nil {
+		log.Errorf("failed to migrate cache keys: %v", err)
+		os.Exit(1)
+	}
+
+	log.Infof("migration finished: scanned %d keys, found %d legacy keys, expired %d, deleted %d",
+		result.Scanned, result.Legacy, result.Expired, result.Deleted)
+}
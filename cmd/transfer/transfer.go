@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dennishilgert/cloud-computing-2/cmd/config"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"github.com/spf13/pflag"
+)
+
+var log = logger.NewLogger("app.transfer")
+
+// Export dumps the cached translations to a JSONL file.
+func Export(args []string) {
+	flags := pflag.NewFlagSet("export-cache", pflag.ExitOnError)
+	output := flags.StringP("output", "o", "", "file to write the entries to")
+	compress := flags.Bool("gzip", false, "compress the output with gzip (default for files ending with .gz)")
+	sourceLang := flags.String("source", "", "only export entries with this source language")
+	targetLang := flags.String("target", "", "only export entries with this target language")
+	flags.Parse(args)
+
+	// the entries are not written to stdout, since the logs are written there
+	if *output == "" {
+		log.Fatal("the output file must be set with --output")
+	}
+
+	cfg := config.MustLoad()
+	transfer := newTransfer(cfg)
+	defer transfer.Close()
+
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("failed to create output file: %v", err)
+	}
+
+	buffered := bufio.NewWriter(file)
+	var (
+		w          io.Writer = buffered
+		gzipWriter *gzip.Writer
+	)
+	if *compress || strings.HasSuffix(*output, ".gz") {
+		gzipWriter = gzip.NewWriter(buffered)
+		w = gzipWriter
+	}
+
+	result, err := transfer.Export(context.Background(), w, cache.TransferFilter{
+		SourceLang: *sourceLang,
+		TargetLang: *targetLang,
+	})
+	if err != nil {
+		log.Fatalf("failed to export cache entries: %v", err)
+	}
+
+	// the writers are closed in order, since the remaining data is only
+	// written to the file on close and a failure would leave it truncated
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			log.Fatalf("failed to compress output file: %v", err)
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatalf("failed to write output file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("failed to close output file: %v", err)
+	}
+
+	log.Infof("exported %d entries, skipped %d entries without metadata", result.Transferred, result.Skipped)
+}
+
+// Import restores cached translations from a JSONL file. Gzip compressed files
+// are detected automatically.
+func Import(args []string) {
+	flags := pflag.NewFlagSet("import-cache", pflag.ExitOnError)
+	input := flags.StringP("input", "i", "-", "file to read the entries from, - for stdin")
+	sourceLang := flags.String("source", "", "only import entries with this source language")
+	targetLang := flags.String("target", "", "only import entries with this target language")
+	batchSize := flags.Int("batch-size", 500, "amount of entries written per pipeline")
+	flags.Parse(args)
+
+	cfg := config.MustLoad()
+	transfer := newTransfer(cfg)
+	defer transfer.Close()
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("failed to open input file: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	// gzip streams are detected by their magic number
+	buffered := bufio.NewReader(r)
+	r = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			log.Fatalf("failed to read gzip compressed input: %v", err)
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	}
+
+	result, err := transfer.Import(context.Background(), r, cache.TransferFilter{
+		SourceLang: *sourceLang,
+		TargetLang: *targetLang,
+	}, max(*batchSize, 1))
+	if err != nil {
+		log.Fatalf("failed to import cache entries: %v", err)
+	}
+
+	log.Infof("imported %d entries, skipped %d entries", result.Transferred, result.Skipped)
+}
+
+// newTransfer creates the transfer for the configured redis cache.
+func newTransfer(cfg *config.Config) cache.Transfer {
+	if cfg.CacheBackend != cache.BackendRedis {
+		log.Fatalf("export and import are not supported for the %s cache backend", cfg.CacheBackend)
+	}
+
+	normalizer, err := normalize.NewNormalizer(cfg.Normalizer)
+	if err != nil {
+		log.Fatalf("failed to create cache key normalizer: %v", err)
+	}
+
+	transfer, err := cache.NewTransfer(cache.Options{
+		Redis:                cfg.Redis,
+		Namespace:            cfg.Namespace,
+		Normalizer:           normalizer,
+		TTL:                  cfg.CacheTTL,
+		PairTTLs:             cfg.CachePairTTLs,
+		CompressionThreshold: cfg.CacheCompressionThreshold,
+	})
+	if err != nil {
+		log.Fatalf("failed to create redis client: %v", err)
+	}
+	return transfer
+}
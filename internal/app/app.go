@@ -3,12 +3,22 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/grpc"
 	"github.com/dennishilgert/cloud-computing-2/internal/app/http"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/metrics"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/pipeline"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/redact"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/stats"
 	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/warmup"
 	"github.com/dennishilgert/cloud-computing-2/pkg/concurrency/runner"
 	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	redis "github.com/redis/go-redis/v9"
 )
 
 var log = logger.NewLogger("app")
@@ -16,47 +26,241 @@ var log = logger.NewLogger("app")
 // App is the main application
 type App interface {
 	Run(ctx context.Context) error
+
+	// Warm pre-populates the cache with the configured phrases and returns
+	// once the warm-up is done.
+	Warm(ctx context.Context) (*warmup.Progress, error)
 }
 
 // Options contains the options for `NewApp`.
 type Options struct {
-	AppPort      int
-	GpcProjectId string
-	RedisHost    string
-	RedisPort    int
+	AppPort                   int
+	GrpcPort                  int
+	ShutdownDelay             time.Duration
+	MetricsEnabled            bool
+	MetricsPort               int
+	AdminToken                string
+	GpcProjectId              string
+	Model                     string
+	UpstreamTimeout           time.Duration
+	CacheBackend              string
+	Redis                     cache.RedisOptions
+	Namespace                 string
+	CacheTTL                  time.Duration
+	CachePairTTLs             []string
+	CacheSlidingExpiration    bool
+	CacheMaxEntrySize         int
+	CacheMemoryMaxBytes       int
+	CacheCompressionThreshold int
+	CacheDiskPath             string
+	CacheDiskCompaction       time.Duration
+	CacheBreaker              cache.BreakerOptions
+	CacheLock                 cache.LockOptions
+	LocalCacheEnabled         bool
+	LocalCacheMaxBytes        int
+	LocalCacheTTL             time.Duration
+	StatsFlushInterval        time.Duration
+	StatsSummaryInterval      time.Duration
+	Redaction                 redact.Options
+	Policy                    policy.Options
+	Normalizer                normalize.Options
+	Warmup                    warmup.Options
 }
 
 type app struct {
-	httpServer http.Server
+	translator    translate.Translator
+	httpServer    http.Server
+	grpcServer    grpc.Server
+	grpcEnabled   bool
+	metricsServer metrics.Server
+	recorder      stats.Recorder
+	maintainer    cache.Maintainer
+	warmup        warmup.Warmup
+	warmupEnabled bool
 }
 
 func NewApp(ctx context.Context, opts Options) (App, error) {
 	translator := translate.NewTranslator(ctx, translate.Options{
 		ProjectId: opts.GpcProjectId,
+		Model:     opts.Model,
 	})
 
-	cache := cache.NewCache(cache.Options{
-		Host: opts.RedisHost,
-		Port: opts.RedisPort,
+	// the metrics measure the calls of the provider, so that the characters
+	// are counted after the redaction
+	var appMetrics metrics.Metrics
+	if opts.MetricsEnabled {
+		appMetrics = metrics.NewMetrics()
+		translator = metrics.NewTranslator(translator, appMetrics)
+	}
+
+	if opts.Redaction.Enabled {
+		redactor, err := redact.NewRedactor(opts.Redaction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create redactor: %w", err)
+		}
+		translator = redact.NewTranslator(translator, redactor)
+		log.Infof("redaction of sensitive entities enabled: %v", opts.Redaction.Entities)
+	}
+
+	policy, err := policy.NewPolicy(opts.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create language policy: %w", err)
+	}
+
+	normalizer, err := normalize.NewNormalizer(opts.Normalizer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache key normalizer: %w", err)
+	}
+
+	cacheOpts := cache.Options{
+		Backend:           opts.CacheBackend,
+		Redis:             opts.Redis,
+		Namespace:         opts.Namespace,
+		Provider:          translator.Provider(),
+		Model:             translator.Model(),
+		Normalizer:        normalizer,
+		TTL:               opts.CacheTTL,
+		PairTTLs:          opts.CachePairTTLs,
+		SlidingExpiration: opts.CacheSlidingExpiration,
+		MaxEntrySize:      opts.CacheMaxEntrySize,
+		MemoryMaxBytes:    opts.CacheMemoryMaxBytes,
+
+		CompressionThreshold:   opts.CacheCompressionThreshold,
+		DiskPath:               opts.CacheDiskPath,
+		DiskCompactionInterval: opts.CacheDiskCompaction,
+	}
+
+	// the redis client is shared by the cache, the statistics and the
+	// translation locks
+	var redisClient redis.UniversalClient
+	readinessChecks := map[string]http.Check{}
+	if opts.CacheBackend == cache.BackendRedis {
+		redisClient, err = cache.NewRedisClient(opts.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create redis client: %w", err)
+		}
+		cacheOpts.Client = redisClient
+		readinessChecks["redis"] = func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}
+	}
+
+	// the statistics are kept in redis, so that they are aggregated across
+	// replicas
+	statsStore := stats.NewMemoryStore()
+	if redisClient != nil {
+		statsStore = stats.NewRedisStore(redisClient, opts.Namespace)
+	}
+	recorder := stats.NewRecorder(statsStore, stats.Options{
+		FlushInterval:   opts.StatsFlushInterval,
+		SummaryInterval: opts.StatsSummaryInterval,
 	})
+	if appMetrics != nil {
+		recorder = metrics.NewRecorder(recorder, appMetrics)
+	}
+
+	// the recorder reports the compression ratio of the stored translations
+	cacheOpts.Observer = recorder
+
+	translationCache, err := cache.NewCache(cacheOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache: %w", err)
+	}
+
+	log.Infof("using %s cache backend", opts.CacheBackend)
+
+	// the maintenance of the cache is run next to the app
+	maintainer, _ := translationCache.(cache.Maintainer)
+
+	// the breaker guards the remote cache only, so that the in-process tier
+	// keeps serving while redis is unavailable
+	var breakerCache cache.BreakerCache
+	if opts.CacheBreaker.Enabled && opts.CacheBackend == cache.BackendRedis {
+		breakerCache = cache.NewBreakerCache(translationCache, opts.CacheBreaker)
+		if appMetrics != nil {
+			appMetrics.RegisterCacheBreaker(breakerCache)
+		}
+		translationCache = breakerCache
+		log.Infof("cache circuit breaker enabled (failure threshold: %d, open duration: %v, operation timeout: %v)",
+			opts.CacheBreaker.FailureThreshold, opts.CacheBreaker.OpenDuration, opts.CacheBreaker.OperationTimeout)
+	}
+
+	// the replicas coordinate their translations with locks in redis, which
+	// are taken through the breaker of the cache
+	locker := cache.NewNoopLocker()
+	if opts.CacheLock.Enabled && redisClient != nil {
+		locker = cache.NewRedisLocker(redisClient, breakerCache, cacheOpts, opts.CacheLock)
+		log.Infof("translation locks across replicas enabled (ttl: %v, wait: %v)", opts.CacheLock.TTL, opts.CacheLock.Wait)
+	}
+
+	// an in-process tier only makes sense in front of a remote cache
+	if opts.LocalCacheEnabled && opts.CacheBackend == cache.BackendRedis {
+		tieredCache, err := cache.NewTieredCache(translationCache, cacheOpts, cache.TieredOptions{
+			MaxBytes: opts.LocalCacheMaxBytes,
+			TTL:      opts.LocalCacheTTL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-process cache: %w", err)
+		}
+		if appMetrics != nil {
+			appMetrics.RegisterCacheTiers(tieredCache)
+		}
+		translationCache = tieredCache
+		log.Infof("in-process cache enabled (max bytes: %d, ttl: %v)", opts.LocalCacheMaxBytes, opts.LocalCacheTTL)
+	}
+
+	pipeline := pipeline.NewPipeline(translator, translationCache, locker, policy, normalizer, recorder, pipeline.Options{
+		UpstreamTimeout:  opts.UpstreamTimeout,
+		LockWait:         opts.CacheLock.Wait,
+		LockPollInterval: opts.CacheLock.PollInterval,
+		MaxEntrySize:     opts.CacheMaxEntrySize,
+	})
+
+	var metricsServer metrics.Server
+	if appMetrics != nil && opts.MetricsPort > 0 {
+		metricsServer = metrics.NewServer(appMetrics, metrics.ServerOptions{
+			Port: opts.MetricsPort,
+		})
+	}
 
 	return &app{
-		httpServer: http.NewHttpServer(translator, cache, http.Options{
-			Port: opts.AppPort,
+		translator: translator,
+		httpServer: http.NewHttpServer(translator, pipeline, translationCache, policy, recorder, http.Options{
+			Port:          opts.AppPort,
+			AdminToken:    opts.AdminToken,
+			ShutdownDelay: opts.ShutdownDelay,
+			Checks:        readinessChecks,
+			Metrics:       appMetrics,
+			ServeMetrics:  opts.MetricsPort == 0,
 		}),
+		grpcServer: grpc.NewGrpcServer(translator, pipeline, policy, grpc.Options{
+			Port: opts.GrpcPort,
+		}),
+		grpcEnabled:   opts.GrpcPort > 0,
+		metricsServer: metricsServer,
+		recorder:      recorder,
+		maintainer:    maintainer,
+		warmup:        warmup.NewWarmup(pipeline, opts.Warmup),
+		warmupEnabled: opts.Warmup.File != "",
 	}, nil
 }
 
 func (a *app) Run(ctx context.Context) error {
 	log.Info("app is starting")
 
-	runner := runner.NewRunnerManager(
+	runners := []runner.Runner{
 		func(ctx context.Context) error {
 			if err := a.httpServer.Run(ctx); err != nil {
 				return fmt.Errorf("failed to run http server: %v", err)
 			}
 			return nil
 		},
+		func(ctx context.Context) error {
+			if err := a.recorder.Run(ctx); err != nil {
+				return fmt.Errorf("failed to run statistics recorder: %v", err)
+			}
+			return nil
+		},
 		func(ctx context.Context) error {
 			if err := a.httpServer.Ready(ctx); err != nil {
 				return fmt.Errorf("http server did not become ready in time: %v", err)
@@ -65,7 +269,85 @@ func (a *app) Run(ctx context.Context) error {
 			<-ctx.Done()
 			return nil
 		},
+	}
+
+	if a.grpcEnabled {
+		runners = append(runners, func(ctx context.Context) error {
+			if err := a.grpcServer.Run(ctx); err != nil {
+				return fmt.Errorf("failed to run grpc server: %v", err)
+			}
+			return nil
+		})
+	}
+
+	if a.metricsServer != nil {
+		runners = append(runners, func(ctx context.Context) error {
+			if err := a.metricsServer.Run(ctx); err != nil {
+				return fmt.Errorf("failed to run metrics server: %v", err)
+			}
+			return nil
+		})
+	}
+
+	if a.maintainer != nil {
+		runners = append(runners, a.runMaintainer)
+	}
+
+	// the warm-up runs in the background, so that requests are served meanwhile
+	if a.warmupEnabled {
+		runners = append(runners, a.warmup.Run)
+	}
+
+	// the translator and the cache are shared by both servers and are closed
+	// once they stopped
+	defer a.translator.Close()
+	if a.maintainer != nil {
+		defer a.closeMaintainer()
+	}
+
+	return runner.NewRunnerManager(runners...).Run(ctx)
+}
+
+// Warm runs the warm-up next to the statistics recorder, so that the
+// statistics of the warm-up are flushed once it is done.
+func (a *app) Warm(ctx context.Context) (*warmup.Progress, error) {
+	var (
+		progress *warmup.Progress
+		warmErr  error
 	)
+	runners := []runner.Runner{
+		func(ctx context.Context) error {
+			if err := a.recorder.Run(ctx); err != nil {
+				return fmt.Errorf("failed to run statistics recorder: %v", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) error {
+			progress, warmErr = a.warmup.Warm(ctx)
+			return nil
+		},
+	}
+	if a.maintainer != nil {
+		runners = append(runners, a.runMaintainer)
+		defer a.closeMaintainer()
+	}
+
+	err := runner.NewRunnerManager(runners...).Run(ctx)
+	if err != nil {
+		return progress, err
+	}
+	return progress, warmErr
+}
+
+func (a *app) runMaintainer(ctx context.Context) error {
+	if err := a.maintainer.Run(ctx); err != nil {
+		return fmt.Errorf("failed to run cache maintenance: %v", err)
+	}
+	return nil
+}
 
-	return runner.Run(ctx)
+func (a *app) closeMaintainer() {
+	if err := a.maintainer.Close(); err != nil {
+		log.Errorf("failed to close cache: %v", err)
+	}
 }
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned while the circuit breaker does not let
+// operations pass through to the cache.
+var ErrCircuitOpen = errors.New("cache circuit breaker is open")
+
+type BreakerOptions struct {
+	Enabled bool
+
+	// FailureThreshold is the amount of consecutive failures that opens the breaker.
+	FailureThreshold int
+
+	// OpenDuration is the time the breaker stays open before a single
+	// operation is let through to probe whether the cache has recovered.
+	OpenDuration time.Duration
+
+	// OperationTimeout bounds the duration of each operation. Zero disables
+	// the deadline.
+	OperationTimeout time.Duration
+}
+
+// BreakerCache is a cache that stops calling an unhealthy cache after
+// repeated failures and fails fast with ErrCircuitOpen instead.
+type BreakerCache interface {
+	Cache
+	State() string
+
+	// Do runs another operation on the cache backend through the breaker,
+	// like the translation locks kept next to the entries.
+	Do(ctx context.Context, op func(ctx context.Context) error) error
+}
+
+type breakerCache struct {
+	next     Cache
+	opts     BreakerOptions
+	state    string
+	failures int
+	openedAt time.Time
+	lock     sync.Mutex
+}
+
+// NewBreakerCache wraps the cache with a circuit breaker. Only the lookups
+// and writes of the translate path are guarded, whereas deletions and
+// invalidations are always passed through, so that their errors are reported.
+func NewBreakerCache(next Cache, opts BreakerOptions) BreakerCache {
+	return &breakerCache{
+		next:  next,
+		opts:  opts,
+		state: BreakerClosed,
+	}
+}
+
+// Add stores the translation unless the breaker is open.
+func (b *breakerCache) Add(ctx context.Context, item Item) error {
+	return b.Do(ctx, func(ctx context.Context) error {
+		return b.next.Add(ctx, item)
+	})
+}
+
+// Get returns the translation unless the breaker is open.
+func (b *breakerCache) Get(ctx context.Context, key Key) (Lookup, error) {
+	var lookup Lookup
+	err := b.Do(ctx, func(ctx context.Context) error {
+		var err error
+		lookup, err = b.next.Get(ctx, key)
+		return err
+	})
+	return lookup, err
+}
+
+// GetMany returns the translations unless the breaker is open.
+func (b *breakerCache) GetMany(ctx context.Context, keys []Key) ([]Lookup, error) {
+	var lookups []Lookup
+	err := b.Do(ctx, func(ctx context.Context) error {
+		var err error
+		lookups, err = b.next.GetMany(ctx, keys)
+		return err
+	})
+	return lookups, err
+}
+
+// SetMany stores the translations unless the breaker is open.
+func (b *breakerCache) SetMany(ctx context.Context, items []Item) error {
+	return b.Do(ctx, func(ctx context.Context) error {
+		return b.next.SetMany(ctx, items)
+	})
+}
+
+// Inspect returns the entry unless the breaker is open.
+func (b *breakerCache) Inspect(ctx context.Context, key Key) (*Entry, bool, error) {
+	var (
+		entry *Entry
+		found bool
+	)
+	err := b.Do(ctx, func(ctx context.Context) error {
+		var err error
+		entry, found, err = b.next.Inspect(ctx, key)
+		return err
+	})
+	return entry, found, err
+}
+
+// Delete passes the deletion through regardless of the state.
+func (b *breakerCache) Delete(ctx context.Context, keys ...Key) (int, error) {
+	return b.next.Delete(ctx, keys...)
+}
+
+// Invalidate passes the invalidation through regardless of the state.
+func (b *breakerCache) Invalidate(ctx context.Context, filter InvalidationFilter) (int, error) {
+	return b.next.Invalidate(ctx, filter)
+}
+
+// State returns the current state of the breaker.
+func (b *breakerCache) State() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// Do runs the operation with the operation timeout if the breaker allows it
+// and records its outcome.
+func (b *breakerCache) Do(ctx context.Context, op func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	opCtx, cancel := ctx, context.CancelFunc(func() {})
+	if b.opts.OperationTimeout > 0 {
+		opCtx, cancel = context.WithTimeout(ctx, b.opts.OperationTimeout)
+	}
+	defer cancel()
+
+	err := op(opCtx)
+	b.record(ctx, err)
+	return err
+}
+
+// allow checks whether an operation may pass. Once the open duration has
+// elapsed, a single operation is let through as probe.
+func (b *breakerCache) allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.opts.OpenDuration {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		log.Info("probing whether the cache has recovered")
+		return nil
+	case BreakerHalfOpen:
+		// the probe is still in flight
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// record updates the state with the outcome of an operation. Operations
+// cancelled by the caller say nothing about the health of the cache.
+func (b *breakerCache) record(ctx context.Context, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch {
+	case err == nil:
+		if b.state != BreakerClosed {
+			log.Info("cache has recovered, closing circuit breaker")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+	case ctx.Err() != nil:
+		// an interrupted probe is repeated with the next operation
+		if b.state == BreakerHalfOpen {
+			b.state = BreakerOpen
+		}
+	default:
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.opts.FailureThreshold {
+			if b.state == BreakerClosed {
+				log.Warnf("opening cache circuit breaker after %d consecutive failures, last error: %v", b.failures, err)
+			}
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTestUnavailable = errors.New("cache unavailable")
+
+func TestBreakerStateMachine(t *testing.T) {
+	type step struct {
+		// elapse lets the open duration pass before the operation
+		elapse bool
+		// cancel cancels the context of the operation
+		cancel bool
+		err    error
+
+		wantOpen  bool
+		wantState string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"success keeps closed", []step{
+			{wantState: BreakerClosed},
+		}},
+		{"failures below threshold", []step{
+			{err: errTestUnavailable, wantState: BreakerClosed},
+			{err: errTestUnavailable, wantState: BreakerClosed},
+		}},
+		{"success resets failures", []step{
+			{err: errTestUnavailable, wantState: BreakerClosed},
+			{err: errTestUnavailable, wantState: BreakerClosed},
+			{wantState: BreakerClosed},
+			{err: errTestUnavailable, wantState: BreakerClosed},
+		}},
+		{"threshold opens", []step{
+			{err: errTestUnavailable, wantState: BreakerClosed},
+			{err: errTestUnavailable, wantState: BreakerClosed},
+			{err: errTestUnavailable, wantState: BreakerOpen},
+			{wantOpen: true, wantState: BreakerOpen},
+		}},
+		{"successful probe closes", []step{
+			{err: errTestUnavailable},
+			{err: errTestUnavailable},
+			{err: errTestUnavailable, wantState: BreakerOpen},
+			{elapse: true, wantState: BreakerClosed},
+			{wantState: BreakerClosed},
+		}},
+		{"failed probe reopens", []step{
+			{err: errTestUnavailable},
+			{err: errTestUnavailable},
+			{err: errTestUnavailable, wantState: BreakerOpen},
+			{elapse: true, err: errTestUnavailable, wantState: BreakerOpen},
+			{wantOpen: true, wantState: BreakerOpen},
+		}},
+		{"cancelled operations are not counted", []step{
+			{err: errTestUnavailable},
+			{err: errTestUnavailable},
+			{cancel: true, err: context.Canceled, wantState: BreakerClosed},
+			{cancel: true, err: context.Canceled, wantState: BreakerClosed},
+		}},
+		{"cancelled probe is repeated", []step{
+			{err: errTestUnavailable},
+			{err: errTestUnavailable},
+			{err: errTestUnavailable, wantState: BreakerOpen},
+			{elapse: true, cancel: true, err: context.Canceled, wantState: BreakerOpen},
+			{elapse: true, wantState: BreakerClosed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreakerCache(nil, BreakerOptions{
+				FailureThreshold: 3,
+				OpenDuration:     time.Hour,
+			}).(*breakerCache)
+
+			for i, s := range tt.steps {
+				if s.elapse {
+					b.openedAt = b.openedAt.Add(-b.opts.OpenDuration)
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				if s.cancel {
+					cancel()
+				}
+				err := b.Do(ctx, func(ctx context.Context) error { return s.err })
+				cancel()
+
+				if open := errors.Is(err, ErrCircuitOpen); open != s.wantOpen {
+					t.Errorf("step %d: Do() = %v, want open %v", i, err, s.wantOpen)
+				}
+				if s.wantState != "" && b.State() != s.wantState {
+					t.Errorf("step %d: State() = %s, want %s", i, b.State(), s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpenLetsSingleProbe(t *testing.T) {
+	b := NewBreakerCache(nil, BreakerOptions{
+		FailureThreshold: 1,
+		OpenDuration:     time.Hour,
+	}).(*breakerCache)
+	_ = b.Do(context.Background(), func(ctx context.Context) error { return errTestUnavailable })
+	b.openedAt = b.openedAt.Add(-b.opts.OpenDuration)
+
+	err := b.Do(context.Background(), func(ctx context.Context) error {
+		if state := b.State(); state != BreakerHalfOpen {
+			t.Errorf("State() during probe = %s, want %s", state, BreakerHalfOpen)
+		}
+		if err := b.Do(ctx, func(ctx context.Context) error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Do() during probe = %v, want %v", err, ErrCircuitOpen)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("State() after probe = %s, want %s", state, BreakerClosed)
+	}
+}
+
+func TestBreakerOperationTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{"timeout", time.Second, true},
+		{"no timeout", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreakerCache(nil, BreakerOptions{
+				FailureThreshold: 1,
+				OpenDuration:     time.Hour,
+				OperationTimeout: tt.timeout,
+			})
+			_ = b.Do(context.Background(), func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); ok != tt.wantDeadline {
+					t.Errorf("operation has deadline = %v, want %v", ok, tt.wantDeadline)
+				}
+				return nil
+			})
+		})
+	}
+}
@@ -2,61 +2,176 @@ package cache
 
 import (
 	"context"
-	"crypto/md5"
 	"fmt"
+	"time"
 
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
 	redis "github.com/redis/go-redis/v9"
 )
 
+var log = logger.NewLogger("app.cache")
+
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendDisk   = "disk"
+)
+
 type Options struct {
-	Host string
-	Port int
-}
+	// Backend selects the cache implementation (redis, memory or disk).
+	Backend string
 
-type Cache interface {
-	Add(ctx context.Context, input string, language string, translation string) error
-	Has(ctx context.Context, input string, language string) (string, bool)
-	Get(ctx context.Context, input string, language string) string
+	// Redis contains the connection settings of the redis backend.
+	Redis RedisOptions
+
+	// Client is used by the redis backend instead of connecting with the
+	// settings, so that its connection pool can be shared.
+	Client redis.UniversalClient
+
+	Namespace  string
+	Provider   string
+	Model      string
+	Normalizer normalize.Normalizer
+
+	// TTL is the expiration of an entry. Zero stores entries without expiration.
+	TTL time.Duration
+
+	// PairTTLs override the TTL per language pair in the form "source:target=ttl",
+	// where either language may be "*".
+	PairTTLs []string
+
+	// SlidingExpiration resets the expiration of an entry on each hit.
+	SlidingExpiration bool
+
+	// MaxEntrySize skips caching entries whose input and translation exceed
+	// this amount of bytes. Zero disables the limit.
+	MaxEntrySize int
+
+	// CompressionThreshold is the size in bytes from which translations are
+	// stored compressed in redis. Zero disables the compression.
+	CompressionThreshold int
+
+	// Observer is notified about the size of each translation stored in redis.
+	Observer StoreObserver
+
+	// MemoryMaxBytes is the memory limit of the memory backend.
+	MemoryMaxBytes int
+
+	// DiskPath is the database file of the disk backend.
+	DiskPath string
+
+	// DiskCompactionInterval defines how often expired entries are removed
+	// from the disk backend. Zero disables the compaction.
+	DiskCompactionInterval time.Duration
 }
 
-type cache struct {
-	client redis.Client
+// Key identifies a cached translation.
+type Key struct {
+	Input      string
+	SourceLang string
+	TargetLang string
 }
 
-func NewCache(opts Options) Cache {
-	return &cache{
-		client: *redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-			Password: "",
-			DB:       0,
-		}),
+// String returns a short representation of the key for logging, which does
+// not contain the input itself.
+func (k Key) String() string {
+	sourceLang := k.SourceLang
+	if sourceLang == "" {
+		sourceLang = AutoDetect
 	}
+	return fmt.Sprintf("%s:%s:%s", sourceLang, k.TargetLang, hashKey(k.Input)[:12])
 }
 
-// Add adds an key/value pair to the cache.
-func (c *cache) Add(ctx context.Context, input string, language string, translation string) error {
-	hashedKey := hashKey(fmt.Sprintf("%s%s", input, language))
-	return c.client.Set(ctx, hashedKey, translation, 0).Err()
+// InvalidationFilter selects the entries to invalidate by language pair and
+// provider. Empty fields match everything.
+type InvalidationFilter struct {
+	SourceLang string
+	TargetLang string
+	Provider   string
 }
 
-// Has checks if an key exists in the cache.
-func (c *cache) Has(ctx context.Context, input string, language string) (string, bool) {
-	hashedKey := hashKey(fmt.Sprintf("%s%s", input, language))
-	result, err := c.client.Exists(ctx, hashedKey).Result()
-	if err != nil {
-		return hashedKey, false
-	}
-	return hashedKey, result > 0
+// IsEmpty reports whether the filter matches every entry.
+func (f InvalidationFilter) IsEmpty() bool {
+	return f.SourceLang == "" && f.TargetLang == "" && f.Provider == ""
+}
+
+func (f InvalidationFilter) matches(key storageKey) bool {
+	return (f.SourceLang == "" || keyPart(f.SourceLang) == key.SourceLang) &&
+		(f.TargetLang == "" || keyPart(f.TargetLang) == key.TargetLang) &&
+		(f.Provider == "" || keyPart(f.Provider) == key.Provider)
 }
 
-// Get returns an key/value pair from the cache by its key.
-func (c *cache) Get(ctx context.Context, input string, language string) string {
-	hashedKey := hashKey(fmt.Sprintf("%s%s", input, language))
-	return c.client.Get(ctx, hashedKey).Val()
+// Item is a translation stored with Add or SetMany.
+type Item struct {
+	Key         Key
+	Translation string
+
+	// DetectedSourceLang is the source language detected by the provider for
+	// keys without source language.
+	DetectedSourceLang string
 }
 
-// hashKey returns the md5 hash of the key.
-func hashKey(key string) string {
-	keyBytes := []byte(key)
-	return fmt.Sprintf("%x", md5.Sum(keyBytes))
+// Lookup is the result of the lookup of a single key with Get or GetMany.
+type Lookup struct {
+	Translation        string
+	DetectedSourceLang string
+	Found              bool
+}
+
+type Cache interface {
+	// Add stores the translation of the item under its key.
+	Add(ctx context.Context, item Item) error
+
+	// Get returns the translation stored under the key in a single round-trip.
+	// A missing entry is reported with Found set to false and a nil error,
+	// whereas an error indicates that the cache is unavailable.
+	Get(ctx context.Context, key Key) (Lookup, error)
+
+	// GetMany returns the lookups of the keys in their order in a single
+	// round-trip. An error indicates that the cache is unavailable.
+	GetMany(ctx context.Context, keys []Key) ([]Lookup, error)
+
+	// SetMany stores the translations in a single round-trip.
+	SetMany(ctx context.Context, items []Item) error
+
+	// Inspect returns the entry stored under the key with its metadata. Unlike
+	// Get it neither counts a hit nor resets the expiration.
+	Inspect(ctx context.Context, key Key) (entry *Entry, found bool, err error)
+
+	// Delete removes the entries stored under the keys and returns the amount
+	// of removed entries.
+	Delete(ctx context.Context, keys ...Key) (int, error)
+
+	// Invalidate removes all entries matching the filter, regardless of the
+	// model and normalizer they have been stored with, and returns the amount
+	// of removed entries.
+	Invalidate(ctx context.Context, filter InvalidationFilter) (int, error)
+}
+
+// Maintainer is implemented by caches that require periodic maintenance. Run
+// must be run as a runner for the lifetime of the cache and Close must be
+// called once the cache is no longer used.
+type Maintainer interface {
+	Run(ctx context.Context) error
+	Close() error
+}
+
+// NewCache creates the cache of the backend selected in the options.
+func NewCache(opts Options) (Cache, error) {
+	ttlPolicy, err := newTTLPolicy(opts.TTL, opts.PairTTLs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Backend {
+	case "", BackendRedis:
+		return newRedisCache(opts, ttlPolicy)
+	case BackendMemory:
+		return newMemoryCache(opts, ttlPolicy), nil
+	case BackendDisk:
+		return newDiskCache(opts, ttlPolicy)
+	default:
+		return nil, fmt.Errorf("unsupported cache backend: %s", opts.Backend)
+	}
 }
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// compressedMarker prefixes compressed values. The byte never occurs in utf-8
+// encoded text, so that uncompressed values written by earlier versions are
+// still read as they are.
+const compressedMarker = 0xFF
+
+// StoreObserver is notified about the size of each stored translation before
+// and after compression.
+type StoreObserver interface {
+	Stored(sourceLang string, targetLang string, rawBytes int, storedBytes int)
+}
+
+// codec compresses values above a size threshold with gzip.
+type codec struct {
+	threshold int
+}
+
+func newCodec(opts Options) codec {
+	return codec{
+		threshold: opts.CompressionThreshold,
+	}
+}
+
+// encode compresses the value if it reaches the threshold and the compressed
+// value is smaller. A threshold of zero disables the compression.
+func (c codec) encode(value string) string {
+	if c.threshold <= 0 || len(value) < c.threshold {
+		return value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(compressedMarker)
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return value
+	}
+	if err := writer.Close(); err != nil {
+		return value
+	}
+
+	if buf.Len() >= len(value) {
+		return value
+	}
+	return buf.String()
+}
+
+// decode decompresses the value if it carries the compression marker.
+func (c codec) decode(value string) (string, error) {
+	if len(value) == 0 || value[0] != compressedMarker {
+		return value, nil
+	}
+
+	reader, err := gzip.NewReader(strings.NewReader(value[1:]))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
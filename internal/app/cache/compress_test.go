@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	repetitive := strings.Repeat("Hallo Welt! ", 100)
+	tests := []struct {
+		name           string
+		threshold      int
+		value          string
+		wantCompressed bool
+	}{
+		{"disabled", 0, repetitive, false},
+		{"below threshold", 2048, repetitive, false},
+		{"at threshold", len(repetitive), repetitive, true},
+		{"above threshold", 64, repetitive, true},
+		{"incompressible", 1, "ab", false},
+		{"empty", 1, "", false},
+		{"unicode", 64, strings.Repeat("Grüße aus Köln 👋 ", 50), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := codec{threshold: tt.threshold}
+			encoded := c.encode(tt.value)
+			if compressed := len(encoded) > 0 && encoded[0] == compressedMarker; compressed != tt.wantCompressed {
+				t.Errorf("encode(%d bytes) compressed = %v, want %v", len(tt.value), compressed, tt.wantCompressed)
+			}
+			if tt.wantCompressed && len(encoded) >= len(tt.value) {
+				t.Errorf("encode(%d bytes) = %d bytes, want fewer bytes", len(tt.value), len(encoded))
+			}
+
+			decoded, err := c.decode(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != tt.value {
+				t.Errorf("decode(encode(%q)) = %q", tt.value, decoded)
+			}
+		})
+	}
+}
+
+func TestCodecDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"uncompressed value of earlier versions", "Hello world", "Hello world", false},
+		{"empty", "", "", false},
+		{"corrupt compressed value", string([]byte{compressedMarker, 'x'}), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec{threshold: 1}.decode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decode(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// diskBucket is the bucket containing the entries.
+var diskBucket = []byte("entries")
+
+// diskHitFlushInterval defines how often the hits collected in memory are
+// written to disk.
+const diskHitFlushInterval = 10 * time.Second
+
+// diskHit contains the hits of an entry that have not been written to disk.
+type diskHit struct {
+	count     int64
+	lastHitAt time.Time
+	ttl       time.Duration
+}
+
+// diskCache is a persistent cache for single-instance deployments that stores
+// the entries in an embedded database file. Each write is committed in a
+// transaction, so that the file stays consistent if the process crashes.
+type diskCache struct {
+	db                 *bolt.DB
+	schema             keySchema
+	ttlPolicy          ttlPolicy
+	slidingExpiration  bool
+	maxEntrySize       int
+	compactionInterval time.Duration
+	hits               map[string]*diskHit
+	lock               sync.Mutex
+}
+
+func newDiskCache(opts Options, ttlPolicy ttlPolicy) (Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.DiskPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory of %s: %w", opts.DiskPath, err)
+	}
+
+	// the file is locked by the process that opened it, so opening it twice
+	// fails instead of blocking
+	db, err := bolt.Open(opts.DiskPath, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is in use by another process", opts.DiskPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", opts.DiskPath, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(diskBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &diskCache{
+		db:                 db,
+		schema:             newKeySchema(opts),
+		ttlPolicy:          ttlPolicy,
+		slidingExpiration:  opts.SlidingExpiration,
+		maxEntrySize:       opts.MaxEntrySize,
+		compactionInterval: opts.DiskCompactionInterval,
+		hits:               map[string]*diskHit{},
+	}, nil
+}
+
+// Add adds an key/value pair to the cache. Entries exceeding the maximum entry
+// size are skipped.
+func (c *diskCache) Add(ctx context.Context, item Item) error {
+	return c.SetMany(ctx, []Item{item})
+}
+
+// SetMany stores the translations in a single transaction. Entries exceeding
+// the maximum entry size are skipped.
+func (c *diskCache) SetMany(ctx context.Context, items []Item) error {
+	now := time.Now()
+	values := make(map[string][]byte, len(items))
+	for _, item := range items {
+		if c.maxEntrySize > 0 && len(item.Key.Input)+len(item.Translation) > c.maxEntrySize {
+			log.Debugf("skipping cache entry exceeding the maximum entry size: %s", item.Key)
+			continue
+		}
+
+		var expiresAt *time.Time
+		if ttl := c.ttlPolicy.ttl(item.Key.SourceLang, item.Key.TargetLang); ttl > 0 {
+			expiration := now.Add(ttl).UTC()
+			expiresAt = &expiration
+		}
+		entry := newMetadata(item, c.schema.provider, c.schema.model, now.UTC()).entry(item.Translation, expiresAt)
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		values[c.schema.key(item.Key)] = value
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	c.lock.Lock()
+	for storageKey := range values {
+		delete(c.hits, storageKey)
+	}
+	c.lock.Unlock()
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(diskBucket)
+		for storageKey, value := range values {
+			if err := bucket.Put([]byte(storageKey), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns an key/value pair from the cache by its key. The hit is
+// collected in memory and written to disk with the next flush, so that
+// lookups do not require a write transaction.
+func (c *diskCache) Get(ctx context.Context, key Key) (Lookup, error) {
+	storageKey := c.schema.key(key)
+	entry, err := c.read(storageKey)
+	if err != nil || entry == nil {
+		return Lookup{}, err
+	}
+
+	var ttl time.Duration
+	if c.slidingExpiration {
+		ttl = c.ttlPolicy.ttl(key.SourceLang, key.TargetLang)
+	}
+
+	c.lock.Lock()
+	hit, ok := c.hits[storageKey]
+	if !ok {
+		hit = &diskHit{}
+		c.hits[storageKey] = hit
+	}
+	hit.count++
+	hit.lastHitAt = time.Now().UTC()
+	hit.ttl = ttl
+	c.lock.Unlock()
+
+	return Lookup{
+		Translation:        entry.Translation,
+		DetectedSourceLang: entry.DetectedSourceLang,
+		Found:              true,
+	}, nil
+}
+
+// GetMany returns the translations of the keys.
+func (c *diskCache) GetMany(ctx context.Context, keys []Key) ([]Lookup, error) {
+	lookups := make([]Lookup, len(keys))
+	for i, key := range keys {
+		var err error
+		lookups[i], err = c.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lookups, nil
+}
+
+// Inspect returns the entry with its metadata, including the hits that have
+// not been written to disk yet.
+func (c *diskCache) Inspect(ctx context.Context, key Key) (*Entry, bool, error) {
+	storageKey := c.schema.key(key)
+	entry, err := c.read(storageKey)
+	if err != nil || entry == nil {
+		return nil, false, err
+	}
+
+	c.lock.Lock()
+	if hit, ok := c.hits[storageKey]; ok {
+		hit.apply(entry)
+	}
+	c.lock.Unlock()
+	return entry, true, nil
+}
+
+// Delete removes the entries from the database.
+func (c *diskCache) Delete(ctx context.Context, keys ...Key) (int, error) {
+	storageKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		storageKeys[i] = []byte(c.schema.key(key))
+	}
+	return c.delete(storageKeys)
+}
+
+// Invalidate removes all entries matching the filter from the database.
+func (c *diskCache) Invalidate(ctx context.Context, filter InvalidationFilter) (int, error) {
+	var storageKeys [][]byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(c.schema.prefix() + ":")
+		cursor := tx.Bucket(diskBucket).Cursor()
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+			if parsed, ok := c.schema.parseKey(string(k)); ok && filter.matches(parsed) {
+				storageKeys = append(storageKeys, bytes.Clone(k))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return c.delete(storageKeys)
+}
+
+// Run periodically writes the collected hits to disk and removes expired
+// entries until the context is done.
+func (c *diskCache) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(diskHitFlushInterval)
+	defer flushTicker.Stop()
+
+	var compactionCh <-chan time.Time
+	if c.compactionInterval > 0 {
+		compactionTicker := time.NewTicker(c.compactionInterval)
+		defer compactionTicker.Stop()
+		compactionCh = compactionTicker.C
+	}
+
+	for {
+		select {
+		case <-flushTicker.C:
+			if err := c.flushHits(); err != nil {
+				log.Warnf("failed to write cache hits to disk: %v", err)
+			}
+		case <-compactionCh:
+			if err := c.compact(); err != nil {
+				log.Warnf("failed to compact disk cache: %v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Close writes the collected hits to disk and closes the database. It must
+// only be called once the cache is no longer used, since requests may still be
+// served after the maintenance stopped.
+func (c *diskCache) Close() error {
+	if err := c.flushHits(); err != nil {
+		log.Warnf("failed to write cache hits to disk: %v", err)
+	}
+	return c.db.Close()
+}
+
+// read returns the entry stored under the key. Expired entries are reported
+// as missing until they are removed by the compaction.
+func (c *diskCache) read(storageKey string) (*Entry, error) {
+	var entry *Entry
+	err := c.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(diskBucket).Get([]byte(storageKey))
+		if value == nil {
+			return nil
+		}
+		entry = &Entry{}
+		return json.Unmarshal(value, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil && entry.expired(time.Now()) {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// delete removes the keys in a single transaction and returns the amount of
+// removed entries.
+func (c *diskCache) delete(storageKeys [][]byte) (int, error) {
+	c.lock.Lock()
+	for _, key := range storageKeys {
+		delete(c.hits, string(key))
+	}
+	c.lock.Unlock()
+
+	deleted := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(diskBucket)
+		for _, key := range storageKeys {
+			if bucket.Get(key) == nil {
+				continue
+			}
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// flushHits writes the collected hits to disk in a single transaction.
+func (c *diskCache) flushHits() error {
+	c.lock.Lock()
+	hits := c.hits
+	c.hits = map[string]*diskHit{}
+	c.lock.Unlock()
+
+	if len(hits) == 0 {
+		return nil
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(diskBucket)
+		for key, hit := range hits {
+			value := bucket.Get([]byte(key))
+			if value == nil {
+				// the entry has been removed in between
+				continue
+			}
+			var entry Entry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			hit.apply(&entry)
+
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compact removes all expired entries. The freed pages are reused by later
+// writes instead of shrinking the file.
+func (c *diskCache) compact() error {
+	now := time.Now()
+	removed := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(diskBucket)
+
+		// the keys are collected first, since deleting while iterating
+		// with a cursor skips entries
+		var expired [][]byte
+		err := bucket.ForEach(func(k []byte, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.expired(now) {
+				expired = append(expired, bytes.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("removed %d expired entries from disk cache", removed)
+	return nil
+}
+
+// apply adds the hits to the entry. With sliding expiration the expiration is
+// reset relative to the last hit.
+func (h *diskHit) apply(entry *Entry) {
+	entry.Hits += h.count
+	lastHitAt := h.lastHitAt
+	entry.LastHitAt = &lastHitAt
+	if h.ttl > 0 {
+		expiresAt := h.lastHitAt.Add(h.ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+}
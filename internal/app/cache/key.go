@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+)
+
+// KeyVersion is the version of the key schema. It must be increased whenever
+// the layout of the keys changes.
+const KeyVersion = 2
+
+// AutoDetect is used as source language if the source language is detected by
+// the translation provider.
+const AutoDetect = "auto"
+
+const (
+	// metaKeySuffix is appended to the key of an entry to build the key of its metadata.
+	metaKeySuffix = ":meta"
+
+	// lockKeySuffix is appended to the key of an entry to build the key of its
+	// translation lock.
+	lockKeySuffix = ":lock"
+)
+
+// keySchema builds the keys of the cache entries in the form
+// <namespace>:v<version>:<source>:<target>:<provider>:<model>:<normalizer>:<sha256 of input>
+type keySchema struct {
+	namespace  string
+	provider   string
+	model      string
+	normalizer normalize.Normalizer
+}
+
+func newKeySchema(opts Options) keySchema {
+	return keySchema{
+		namespace:  opts.Namespace,
+		provider:   opts.Provider,
+		model:      opts.Model,
+		normalizer: opts.Normalizer,
+	}
+}
+
+// key returns the storage key for the input translated from the source into
+// the target language. The input is normalized before it is hashed.
+func (s keySchema) key(key Key) string {
+	sourceLang := key.SourceLang
+	if sourceLang == "" {
+		sourceLang = AutoDetect
+	}
+	return strings.Join([]string{
+		s.prefix(),
+		keyPart(sourceLang),
+		keyPart(key.TargetLang),
+		keyPart(s.provider),
+		keyPart(s.model),
+		keyPart(s.normalizer.Version()),
+		hashKey(s.normalizer.Normalize(key.Input)),
+	}, ":")
+}
+
+// prefix returns the prefix shared by all keys of the current schema version.
+func (s keySchema) prefix() string {
+	return fmt.Sprintf("%s:v%d", keyPart(s.namespace), KeyVersion)
+}
+
+// pattern returns the SCAN pattern matching the keys of the language pair and
+// provider. An empty language or provider matches every language or provider.
+func (s keySchema) pattern(sourceLang string, targetLang string, provider string) string {
+	parts := []string{s.prefix()}
+	for _, part := range []string{sourceLang, targetLang, provider} {
+		if part == "" {
+			part = wildcard
+		}
+		parts = append(parts, keyPart(part))
+	}
+	return strings.Join(append(parts, wildcard), ":")
+}
+
+// storageKey contains the parts of a key of the current schema.
+type storageKey struct {
+	SourceLang string
+	TargetLang string
+	Provider   string
+	Model      string
+}
+
+// parseKey parses a key of the current schema. Keys of other schema versions,
+// metadata keys and lock keys are rejected.
+func (s keySchema) parseKey(key string) (storageKey, bool) {
+	if strings.HasSuffix(key, metaKeySuffix) || !strings.HasPrefix(key, s.prefix()+":") {
+		return storageKey{}, false
+	}
+	parts := strings.Split(strings.TrimPrefix(key, s.prefix()+":"), ":")
+	if len(parts) != 6 {
+		return storageKey{}, false
+	}
+	return storageKey{
+		SourceLang: parts[0],
+		TargetLang: parts[1],
+		Provider:   parts[2],
+		Model:      parts[3],
+	}, true
+}
+
+// withProvider returns a copy of the schema for another provider and model.
+func (s keySchema) withProvider(provider string, model string) keySchema {
+	s.provider = provider
+	s.model = model
+	return s
+}
+
+// keyPart escapes the separator, so that a part can never be confused with
+// the boundary between two parts.
+func keyPart(part string) string {
+	return strings.ReplaceAll(strings.ToLower(part), ":", "_")
+}
+
+// hashKey returns the hex encoded sha256 hash of the input.
+func hashKey(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+)
+
+func newTestSchema(t *testing.T, provider string, model string) keySchema {
+	t.Helper()
+	normalizer, err := normalize.NewNormalizer(normalize.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keySchema{
+		namespace:  "translator",
+		provider:   provider,
+		model:      model,
+		normalizer: normalizer,
+	}
+}
+
+func TestKeySchemaKeyIsUnique(t *testing.T) {
+	base := Key{Input: "Hallo Welt", SourceLang: "de", TargetLang: "en"}
+	tests := []struct {
+		name     string
+		key      Key
+		provider string
+		model    string
+	}{
+		{"base", base, "google", "general/nmt"},
+		{"other input", Key{Input: "Hallo", SourceLang: "de", TargetLang: "en"}, "google", "general/nmt"},
+		{"other source", Key{Input: "Hallo Welt", SourceLang: "nl", TargetLang: "en"}, "google", "general/nmt"},
+		{"detected source", Key{Input: "Hallo Welt", TargetLang: "en"}, "google", "general/nmt"},
+		{"other target", Key{Input: "Hallo Welt", SourceLang: "de", TargetLang: "fr"}, "google", "general/nmt"},
+		{"swapped languages", Key{Input: "Hallo Welt", SourceLang: "en", TargetLang: "de"}, "google", "general/nmt"},
+		{"regional target", Key{Input: "Hallo Welt", SourceLang: "de", TargetLang: "en-GB"}, "google", "general/nmt"},
+		{"other provider", base, "deepl", "general/nmt"},
+		{"other model", base, "google", "general/base"},
+		{"separator in provider", base, "google:general", "nmt"},
+		{"separator in model", base, "google", "general:nmt"},
+	}
+
+	seen := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := newTestSchema(t, tt.provider, tt.model).key(tt.key)
+			if other, ok := seen[key]; ok {
+				t.Errorf("key(%v) = %q collides with %q", tt.key, key, other)
+			}
+			seen[key] = tt.name
+		})
+	}
+}
+
+func TestKeySchemaKeyIsStable(t *testing.T) {
+	schema := newTestSchema(t, "google", "general/nmt")
+	tests := []struct {
+		name string
+		a    Key
+		b    Key
+	}{
+		{"language case", Key{Input: "Hallo", SourceLang: "de", TargetLang: "en-GB"}, Key{Input: "Hallo", SourceLang: "DE", TargetLang: "en-gb"}},
+		{"detected source", Key{Input: "Hallo", TargetLang: "en"}, Key{Input: "Hallo", SourceLang: AutoDetect, TargetLang: "en"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := schema.key(tt.a), schema.key(tt.b); a != b {
+				t.Errorf("key(%v) = %q, key(%v) = %q, want equal keys", tt.a, a, tt.b, b)
+			}
+		})
+	}
+}
+
+func TestKeySchemaParseKey(t *testing.T) {
+	schema := newTestSchema(t, "google", "general/nmt")
+	key := schema.key(Key{Input: "Hallo", SourceLang: "de", TargetLang: "en"})
+
+	tests := []struct {
+		name   string
+		key    string
+		want   storageKey
+		wantOk bool
+	}{
+		{"entry", key, storageKey{SourceLang: "de", TargetLang: "en", Provider: "google", Model: "general/nmt"}, true},
+		{"metadata", key + metaKeySuffix, storageKey{}, false},
+		{"lock", key + lockKeySuffix, storageKey{}, false},
+		{"other version", "translator:v1:de:en:google:general/nmt:n1:abc", storageKey{}, false},
+		{"other namespace", "other:v2:de:en:google:general/nmt:n1:abc", storageKey{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := schema.parseKey(tt.key)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseKey(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// releaseTimeout bounds the release of a lock, which runs detached from the
+// context of the translation that held it.
+const releaseTimeout = time.Second
+
+// releaseScript deletes the lock only if it is still held with the token, so
+// that a lock that expired and has been acquired by another replica is kept.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type LockOptions struct {
+	Enabled bool
+
+	// TTL is the expiration of a lock, so that a lock of a crashed replica
+	// does not block the key forever.
+	TTL time.Duration
+
+	// Wait is the time to wait for the translation of another replica before
+	// translating directly.
+	Wait time.Duration
+
+	// PollInterval defines how often the cache is checked while waiting.
+	PollInterval time.Duration
+}
+
+// Locker coordinates the translation of the same key across replicas.
+type Locker interface {
+	// Lock tries to acquire the lock of the key without blocking. The release
+	// function must be called once the translation has been cached.
+	Lock(ctx context.Context, key Key) (release func(), acquired bool, err error)
+}
+
+type redisLocker struct {
+	client  redis.UniversalClient
+	breaker BreakerCache
+	schema  keySchema
+	ttl     time.Duration
+}
+
+// NewRedisLocker creates a locker that holds the locks as short-lived redis
+// keys next to the entries. If the cache has a circuit breaker, the locks are
+// taken through it, so that they fail fast while redis is unavailable. The
+// breaker may be nil.
+func NewRedisLocker(client redis.UniversalClient, breaker BreakerCache, opts Options, lockOpts LockOptions) Locker {
+	return &redisLocker{
+		client:  client,
+		breaker: breaker,
+		schema:  newKeySchema(opts),
+		ttl:     lockOpts.TTL,
+	}
+}
+
+// Lock sets the lock key with a random token if it does not exist yet.
+func (l *redisLocker) Lock(ctx context.Context, key Key) (func(), bool, error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	lockKey := l.schema.key(key) + lockKeySuffix
+	var acquired bool
+	err = l.do(ctx, func(ctx context.Context) error {
+		var err error
+		acquired, err = l.client.SetNX(ctx, lockKey, token, l.ttl).Result()
+		return err
+	})
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	release := func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+		err := l.do(releaseCtx, func(ctx context.Context) error {
+			return releaseScript.Run(ctx, l.client, []string{lockKey}, token).Err()
+		})
+		if err != nil {
+			log.Warnf("failed to release translation lock, it expires in %v: %s, reason: %v", l.ttl, key, err)
+		}
+	}
+	return release, true, nil
+}
+
+// do runs the operation through the breaker if there is one.
+func (l *redisLocker) do(ctx context.Context, op func(ctx context.Context) error) error {
+	if l.breaker == nil {
+		return op(ctx)
+	}
+	return l.breaker.Do(ctx, op)
+}
+
+type noopLocker struct{}
+
+// NewNoopLocker creates a locker that always acquires the lock. It is used if
+// there is only a single replica, where singleflight already coalesces the
+// translations.
+func NewNoopLocker() Locker {
+	return noopLocker{}
+}
+
+func (noopLocker) Lock(ctx context.Context, key Key) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// lockToken returns a random token identifying the holder of a lock.
+func lockToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the minimum time between two scans for expired entries,
+// since a scan walks all entries while holding the lock.
+const sweepInterval = time.Minute
+
+// entryOverhead approximates the memory used by an entry besides its key and
+// value, i.e. the list element, the map bucket and the expiration.
+const entryOverhead = 96
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	size      int
+	expiresAt time.Time
+}
+
+// expired checks if the entry is expired. Entries with a zero expiration never expire.
+func (e *lruEntry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// lru is a thread-safe in-memory store that evicts the least recently used
+// entries once its memory limit is reached.
+// The sizeOf function returns the approximate amount of memory used by a value.
+type lru[V any] struct {
+	maxBytes  int
+	usedBytes int
+	sizeOf    func(value V) int
+	items     map[string]*list.Element
+	order     *list.List
+	lastSweep time.Time
+	lock      sync.Mutex
+}
+
+func newLRU[V any](maxBytes int, sizeOf func(value V) int) *lru[V] {
+	return &lru[V]{
+		maxBytes: maxBytes,
+		sizeOf:   sizeOf,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// get returns the value of the key if it exists and is not expired.
+func (l *lru[V]) get(key string) (V, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var zero V
+	elem, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := elem.Value.(*lruEntry[V])
+	if entry.expired(time.Now()) {
+		l.remove(elem)
+		return zero, false
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// touch returns the value of the key like get and resets its expiration to
+// the ttl. The expiration is reset in place, so that a value stored in the
+// meantime is never replaced.
+func (l *lru[V]) touch(key string, ttl time.Duration) (V, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var zero V
+	elem, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := elem.Value.(*lruEntry[V])
+	now := time.Now()
+	if entry.expired(now) {
+		l.remove(elem)
+		return zero, false
+	}
+	entry.expiresAt = now.Add(ttl)
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// peek returns the value of the key and its expiration without marking it as
+// recently used.
+func (l *lru[V]) peek(key string) (V, time.Time, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var zero V
+	elem, ok := l.items[key]
+	if !ok {
+		return zero, time.Time{}, false
+	}
+	entry := elem.Value.(*lruEntry[V])
+	if entry.expired(time.Now()) {
+		return zero, time.Time{}, false
+	}
+	return entry.value, entry.expiresAt, true
+}
+
+// set stores the value under the key. A ttl of zero stores the value without
+// expiration. Values larger than the memory limit are not stored.
+func (l *lru[V]) set(key string, value V, ttl time.Duration) {
+	entry := &lruEntry[V]{
+		key:   key,
+		value: value,
+		size:  len(key) + l.sizeOf(value) + entryOverhead,
+	}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	if entry.size > l.maxBytes {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if elem, ok := l.items[key]; ok {
+		l.remove(elem)
+	}
+	l.items[key] = l.order.PushFront(entry)
+	l.usedBytes += entry.size
+
+	// expired entries are evicted before valid ones by an occasional sweep,
+	// in between only the least recently used entries are evicted
+	if l.usedBytes > l.maxBytes && time.Since(l.lastSweep) >= sweepInterval {
+		l.removeExpired()
+	}
+	for l.usedBytes > l.maxBytes {
+		l.remove(l.order.Back())
+	}
+}
+
+// removeExpired removes all expired entries. The lock must be held by the caller.
+func (l *lru[V]) removeExpired() {
+	now := time.Now()
+	l.lastSweep = now
+	for elem := l.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*lruEntry[V]).expired(now) {
+			l.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+// delete removes the key from the store and reports whether it was present.
+func (l *lru[V]) delete(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	elem, ok := l.items[key]
+	if ok {
+		l.remove(elem)
+	}
+	return ok
+}
+
+// deleteMatching removes all keys accepted by the match function and returns
+// the amount of removed keys.
+func (l *lru[V]) deleteMatching(match func(key string) bool) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	deleted := 0
+	for key, elem := range l.items {
+		if match(key) {
+			l.remove(elem)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// remove removes the element. The lock must be held by the caller.
+func (l *lru[V]) remove(elem *list.Element) {
+	entry := l.order.Remove(elem).(*lruEntry[V])
+	delete(l.items, entry.key)
+	l.usedBytes -= entry.size
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEntrySize is the size of an entry with a single character key and value.
+const testEntrySize = 2 + entryOverhead
+
+func newTestLRU(entries int) *lru[string] {
+	return newLRU[string](entries*testEntrySize, func(value string) int { return len(value) })
+}
+
+// keys returns the keys of the store from the most to the least recently used.
+func (l *lru[V]) keys() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var keys []string
+	for elem := l.order.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(*lruEntry[V]).key)
+	}
+	return keys
+}
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  func(l *lru[string])
+		want []string
+	}{
+		{"within limit", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+		}, []string{"b", "a"}},
+		{"evicts least recently set", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.set("c", "3", 0)
+			l.set("d", "4", 0)
+		}, []string{"d", "c", "b"}},
+		{"get marks as recently used", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.set("c", "3", 0)
+			l.get("a")
+			l.set("d", "4", 0)
+		}, []string{"d", "a", "c"}},
+		{"peek does not mark as recently used", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.set("c", "3", 0)
+			l.peek("a")
+			l.set("d", "4", 0)
+		}, []string{"d", "c", "b"}},
+		{"replacing keeps a single entry", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.set("a", "3", 0)
+		}, []string{"a", "b"}},
+		{"oversized value is not stored", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", strings.Repeat("x", 4*testEntrySize), 0)
+		}, []string{"a"}},
+		{"delete", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.delete("a")
+		}, []string{"b"}},
+		{"delete matching", func(l *lru[string]) {
+			l.set("a", "1", 0)
+			l.set("b", "2", 0)
+			l.set("c", "3", 0)
+			l.deleteMatching(func(key string) bool { return key != "b" })
+		}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLRU(3)
+			tt.ops(l)
+			if got := l.keys(); !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+			if l.usedBytes != len(tt.want)*testEntrySize {
+				t.Errorf("usedBytes = %d, want %d", l.usedBytes, len(tt.want)*testEntrySize)
+			}
+		})
+	}
+}
+
+func TestLRUExpiration(t *testing.T) {
+	l := newTestLRU(3)
+	l.set("a", "1", time.Nanosecond)
+	l.set("b", "2", time.Hour)
+	l.set("c", "3", 0)
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"a", false},
+		{"b", true},
+		{"c", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if _, ok := l.get(tt.key); ok != tt.want {
+				t.Errorf("get(%q) found = %v, want %v", tt.key, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLRUTouch(t *testing.T) {
+	l := newTestLRU(3)
+	l.set("a", "1", time.Millisecond)
+	if value, ok := l.touch("a", time.Hour); !ok || value != "1" {
+		t.Fatalf("touch(%q) = %q, %v, want %q, true", "a", value, ok, "1")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := l.get("a"); !ok {
+		t.Error("touched entry expired with its previous ttl")
+	}
+	if _, ok := l.touch("missing", time.Hour); ok {
+		t.Error("touch of a missing key found a value")
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// memoryCache is a bounded in-memory cache for single-instance deployments
+// that do not want to run redis.
+type memoryCache struct {
+	store             *lru[*memoryEntry]
+	schema            keySchema
+	ttlPolicy         ttlPolicy
+	slidingExpiration bool
+	maxEntrySize      int
+}
+
+// memoryEntry is a translation with its metadata. The hits are updated in
+// place, so that they do not require to replace the entry.
+type memoryEntry struct {
+	translation string
+	meta        metadata
+	hits        atomic.Int64
+	lastHitAt   atomic.Int64
+}
+
+// size returns the approximate amount of memory used by the entry.
+func (e *memoryEntry) size() int {
+	return len(e.translation) + len(e.meta.Text) + len(e.meta.SourceLang) + len(e.meta.TargetLang) +
+		len(e.meta.Provider) + len(e.meta.Model) + len(e.meta.DetectedLang)
+}
+
+func newMemoryCache(opts Options, ttlPolicy ttlPolicy) Cache {
+	return &memoryCache{
+		store:             newLRU(opts.MemoryMaxBytes, (*memoryEntry).size),
+		schema:            newKeySchema(opts),
+		ttlPolicy:         ttlPolicy,
+		slidingExpiration: opts.SlidingExpiration,
+		maxEntrySize:      opts.MaxEntrySize,
+	}
+}
+
+// Add adds an key/value pair to the cache. Entries exceeding the maximum entry
+// size are skipped.
+func (c *memoryCache) Add(ctx context.Context, item Item) error {
+	if c.maxEntrySize > 0 && len(item.Key.Input)+len(item.Translation) > c.maxEntrySize {
+		log.Debugf("skipping cache entry exceeding the maximum entry size: %s", item.Key)
+		return nil
+	}
+	entry := &memoryEntry{
+		translation: item.Translation,
+		meta:        newMetadata(item, c.schema.provider, c.schema.model, time.Now()),
+	}
+	c.store.set(c.schema.key(item.Key), entry, c.ttlPolicy.ttl(item.Key.SourceLang, item.Key.TargetLang))
+	return nil
+}
+
+// Get returns an key/value pair from the cache by its key and counts the hit.
+// With sliding expiration the ttl of the entry is reset.
+func (c *memoryCache) Get(ctx context.Context, key Key) (Lookup, error) {
+	storageKey := c.schema.key(key)
+	var (
+		entry *memoryEntry
+		ok    bool
+	)
+	if ttl := c.ttlPolicy.ttl(key.SourceLang, key.TargetLang); c.slidingExpiration && ttl > 0 {
+		entry, ok = c.store.touch(storageKey, ttl)
+	} else {
+		entry, ok = c.store.get(storageKey)
+	}
+	if !ok {
+		return Lookup{}, nil
+	}
+	entry.hits.Add(1)
+	entry.lastHitAt.Store(time.Now().UnixNano())
+	return Lookup{
+		Translation:        entry.translation,
+		DetectedSourceLang: entry.meta.DetectedLang,
+		Found:              true,
+	}, nil
+}
+
+// GetMany returns the translations of the keys.
+func (c *memoryCache) GetMany(ctx context.Context, keys []Key) ([]Lookup, error) {
+	lookups := make([]Lookup, len(keys))
+	for i, key := range keys {
+		lookups[i], _ = c.Get(ctx, key)
+	}
+	return lookups, nil
+}
+
+// SetMany stores the translations.
+func (c *memoryCache) SetMany(ctx context.Context, items []Item) error {
+	for _, item := range items {
+		if err := c.Add(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inspect returns the entry with its metadata.
+func (c *memoryCache) Inspect(ctx context.Context, key Key) (*Entry, bool, error) {
+	entry, expiration, ok := c.store.peek(c.schema.key(key))
+	if !ok {
+		return nil, false, nil
+	}
+
+	var expiresAt *time.Time
+	if !expiration.IsZero() {
+		expiration = expiration.UTC().Truncate(time.Second)
+		expiresAt = &expiration
+	}
+	meta := entry.meta
+	meta.Hits = entry.hits.Load()
+	if lastHitAt := entry.lastHitAt.Load(); lastHitAt > 0 {
+		meta.LastHitAt = time.Unix(0, lastHitAt)
+	}
+	return meta.entry(entry.translation, expiresAt), true, nil
+}
+
+// Delete removes the entries from the store.
+func (c *memoryCache) Delete(ctx context.Context, keys ...Key) (int, error) {
+	deleted := 0
+	for _, key := range keys {
+		if c.store.delete(c.schema.key(key)) {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Invalidate removes all entries matching the filter from the store.
+func (c *memoryCache) Invalidate(ctx context.Context, filter InvalidationFilter) (int, error) {
+	return c.store.deleteMatching(func(key string) bool {
+		parsed, ok := c.schema.parseKey(key)
+		return ok && filter.matches(parsed)
+	}), nil
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+	metaFieldSourceLang   = "source_lang"
+	metaFieldTargetLang   = "target_lang"
+	metaFieldProvider     = "provider"
+	metaFieldModel        = "model"
+	metaFieldText         = "text"
+	metaFieldCreatedAt    = "created_at"
+	metaFieldHits         = "hits"
+	metaFieldLastHitAt    = "last_hit_at"
+	metaFieldDetectedLang = "detected_lang"
+)
+
+// hitScript counts a hit in the metadata of an entry and returns the detected
+// source language stored with it, so that the lookup needs no additional
+// round-trip. The metadata is only updated if it exists, so that no metadata
+// without expiration is left behind for entries that expired in between. A
+// positive ttl resets the expiration.
+var hitScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return ''
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+if tonumber(ARGV[4]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[4])
+end
+return redis.call('HGET', KEYS[1], ARGV[5]) or ''
+`)
+
+// metadata describes a cache entry. It is stored next to the translation.
+type metadata struct {
+	SourceLang string
+	TargetLang string
+	Provider   string
+	Model      string
+	Text       string
+	CreatedAt  time.Time
+	Hits       int64
+
+	// DetectedLang is the source language detected by the provider. It is
+	// empty for entries with a requested source language.
+	DetectedLang string
+
+	// LastHitAt is zero if the entry has not been hit yet.
+	LastHitAt time.Time
+}
+
+func newMetadata(item Item, provider string, model string, createdAt time.Time) metadata {
+	sourceLang := item.Key.SourceLang
+	if sourceLang == "" {
+		sourceLang = AutoDetect
+	}
+	return metadata{
+		SourceLang:   keyPart(sourceLang),
+		TargetLang:   keyPart(item.Key.TargetLang),
+		Provider:     keyPart(provider),
+		Model:        keyPart(model),
+		Text:         item.Key.Input,
+		CreatedAt:    createdAt,
+		DetectedLang: item.DetectedSourceLang,
+	}
+}
+
+// fields returns the metadata as redis hash fields.
+func (m metadata) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		metaFieldSourceLang: m.SourceLang,
+		metaFieldTargetLang: m.TargetLang,
+		metaFieldProvider:   m.Provider,
+		metaFieldModel:      m.Model,
+		metaFieldText:       m.Text,
+		metaFieldCreatedAt:  m.CreatedAt.UTC().Format(time.RFC3339),
+		metaFieldHits:       m.Hits,
+	}
+	if !m.LastHitAt.IsZero() {
+		fields[metaFieldLastHitAt] = m.LastHitAt.UTC().Format(time.RFC3339)
+	}
+	if m.DetectedLang != "" {
+		fields[metaFieldDetectedLang] = m.DetectedLang
+	}
+	return fields
+}
+
+// entry returns the portable representation of the entry.
+func (m metadata) entry(translation string, expiresAt *time.Time) *Entry {
+	entry := &Entry{
+		SourceLang:         m.SourceLang,
+		TargetLang:         m.TargetLang,
+		Provider:           m.Provider,
+		Model:              m.Model,
+		Text:               m.Text,
+		Translation:        translation,
+		CreatedAt:          m.CreatedAt,
+		Hits:               m.Hits,
+		ExpiresAt:          expiresAt,
+		DetectedSourceLang: m.DetectedLang,
+	}
+	if !m.LastHitAt.IsZero() {
+		lastHitAt := m.LastHitAt
+		entry.LastHitAt = &lastHitAt
+	}
+	return entry
+}
+
+// parseMetadata parses the metadata from redis hash fields. The languages,
+// provider and model fall back to the ones of the key, since metadata written
+// by earlier versions does not contain them.
+func parseMetadata(key storageKey, fields map[string]string) metadata {
+	createdAt, _ := time.Parse(time.RFC3339, fields[metaFieldCreatedAt])
+	lastHitAt, _ := time.Parse(time.RFC3339, fields[metaFieldLastHitAt])
+	hits, _ := strconv.ParseInt(fields[metaFieldHits], 10, 64)
+	return metadata{
+		SourceLang:   fieldOrDefault(fields, metaFieldSourceLang, key.SourceLang),
+		TargetLang:   fieldOrDefault(fields, metaFieldTargetLang, key.TargetLang),
+		Provider:     fieldOrDefault(fields, metaFieldProvider, key.Provider),
+		Model:        fieldOrDefault(fields, metaFieldModel, key.Model),
+		Text:         fields[metaFieldText],
+		CreatedAt:    createdAt,
+		Hits:         hits,
+		LastHitAt:    lastHitAt,
+		DetectedLang: fields[metaFieldDetectedLang],
+	}
+}
+
+func fieldOrDefault(fields map[string]string, name string, defaultVal string) string {
+	if value, ok := fields[name]; ok && value != "" {
+		return value
+	}
+	return defaultVal
+}
+
+// metaKey returns the key of the metadata of the entry stored under the key.
+func metaKey(storageKey string) string {
+	return storageKey + metaKeySuffix
+}
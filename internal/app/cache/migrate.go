@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// legacyKeyPattern matches the keys written before the versioned key schema,
+// which are a bare md5 hash optionally prefixed with a normalizer version.
+var legacyKeyPattern = regexp.MustCompile(`^(n\d+(\.[a-z0-9]+)*:)?[0-9a-f]{32}$`)
+
+type MigrateOptions struct {
+	// ExpireAfter sets a ttl on legacy keys instead of deleting them
+	// immediately. Zero deletes the keys.
+	ExpireAfter time.Duration
+
+	// DryRun only counts the legacy keys without modifying them.
+	DryRun bool
+
+	// SampleSize is the amount of legacy keys returned in the result.
+	SampleSize int
+}
+
+// MigrationResult contains the outcome of a migration.
+type MigrationResult struct {
+	Scanned int
+	Legacy  int
+	Expired int
+	Deleted int
+
+	// Sample contains the first legacy keys found.
+	Sample []string
+}
+
+type Migrator interface {
+	Migrate(ctx context.Context, opts MigrateOptions) (*MigrationResult, error)
+	Close() error
+}
+
+type migrator struct {
+	client redis.UniversalClient
+}
+
+// NewMigrator creates a migrator for the redis cache described by the options.
+func NewMigrator(opts Options) (Migrator, error) {
+	client, err := NewRedisClient(opts.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrator{
+		client: client,
+	}, nil
+}
+
+// Migrate expires or deletes all legacy keys. Legacy keys can not be rewritten
+// to the current schema, since the md5 hash does neither contain the source
+// text nor the source language.
+func (m *migrator) Migrate(ctx context.Context, opts MigrateOptions) (*MigrationResult, error) {
+	result := &MigrationResult{}
+
+	err := scanKeys(ctx, m.client, "*", func(keys []string) error {
+		result.Scanned += len(keys)
+
+		legacy := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if legacyKeyPattern.MatchString(key) {
+				legacy = append(legacy, key)
+			}
+		}
+		result.Legacy += len(legacy)
+		if missing := opts.SampleSize - len(result.Sample); missing > 0 {
+			result.Sample = append(result.Sample, legacy[:min(missing, len(legacy))]...)
+		}
+		log.Debugf("scanned %d keys, found %d legacy keys", result.Scanned, result.Legacy)
+
+		if len(legacy) == 0 || opts.DryRun {
+			return nil
+		}
+		return m.migrateBatch(ctx, legacy, opts, result)
+	})
+	return result, err
+}
+
+// migrateBatch expires or deletes the given keys in a single pipeline. Each
+// key is handled by its own command, so that the batch works on a cluster.
+func (m *migrator) migrateBatch(ctx context.Context, keys []string, opts MigrateOptions, result *MigrationResult) error {
+	pipe := m.client.Pipeline()
+	expireCmds := make([]*redis.BoolCmd, 0, len(keys))
+	unlinkCmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, key := range keys {
+		if opts.ExpireAfter > 0 {
+			expireCmds = append(expireCmds, pipe.Expire(ctx, key, opts.ExpireAfter))
+		} else {
+			unlinkCmds = append(unlinkCmds, pipe.Unlink(ctx, key))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	for _, cmd := range expireCmds {
+		if cmd.Val() {
+			result.Expired++
+		}
+	}
+	for _, cmd := range unlinkCmds {
+		result.Deleted += int(cmd.Val())
+	}
+	return nil
+}
+
+// Close closes the redis client.
+func (m *migrator) Close() error {
+	return m.client.Close()
+}
@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+type redisCache struct {
+	client            redis.UniversalClient
+	schema            keySchema
+	ttlPolicy         ttlPolicy
+	slidingExpiration bool
+	maxEntrySize      int
+	codec             codec
+	observer          StoreObserver
+}
+
+func newRedisCache(opts Options, ttlPolicy ttlPolicy) (Cache, error) {
+	client := opts.Client
+	if client == nil {
+		var err error
+		if client, err = NewRedisClient(opts.Redis); err != nil {
+			return nil, err
+		}
+	}
+
+	return &redisCache{
+		client:            client,
+		schema:            newKeySchema(opts),
+		ttlPolicy:         ttlPolicy,
+		slidingExpiration: opts.SlidingExpiration,
+		maxEntrySize:      opts.MaxEntrySize,
+		codec:             newCodec(opts),
+		observer:          opts.Observer,
+	}, nil
+}
+
+// Add adds an key/value pair to the cache. Entries exceeding the maximum entry
+// size are skipped.
+func (c *redisCache) Add(ctx context.Context, item Item) error {
+	return c.SetMany(ctx, []Item{item})
+}
+
+// SetMany stores the translations with their metadata and expiration in a
+// single pipeline. Entries exceeding the maximum entry size are skipped.
+func (c *redisCache) SetMany(ctx context.Context, items []Item) error {
+	now := time.Now()
+	pipe := c.client.Pipeline()
+	values := make([]string, len(items))
+	stored := make([]bool, len(items))
+	for i, item := range items {
+		if c.maxEntrySize > 0 && len(item.Key.Input)+len(item.Translation) > c.maxEntrySize {
+			log.Debugf("skipping cache entry exceeding the maximum entry size: %s", item.Key)
+			continue
+		}
+		storageKey := c.schema.key(item.Key)
+		ttl := c.ttlPolicy.ttl(item.Key.SourceLang, item.Key.TargetLang)
+		values[i] = c.codec.encode(item.Translation)
+		stored[i] = true
+
+		// the metadata keeps the source text, so that entries can be exported, and
+		// is replaced as a whole, so that no hits of a previous entry are kept
+		pipe.Set(ctx, storageKey, values[i], ttl)
+		pipe.Unlink(ctx, metaKey(storageKey))
+		pipe.HSet(ctx, metaKey(storageKey), newMetadata(item, c.schema.provider, c.schema.model, now).fields())
+		if ttl > 0 {
+			pipe.Expire(ctx, metaKey(storageKey), ttl)
+		}
+	}
+	if pipe.Len() == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if c.observer != nil {
+		for i, item := range items {
+			if stored[i] {
+				c.observer.Stored(item.Key.SourceLang, item.Key.TargetLang, len(item.Translation), len(values[i]))
+			}
+		}
+	}
+	return nil
+}
+
+// Get returns an key/value pair from the cache by its key and counts the hit
+// in the metadata within the same round-trip. With sliding expiration the ttl
+// of the entry is reset.
+func (c *redisCache) Get(ctx context.Context, key Key) (Lookup, error) {
+	lookups, err := c.GetMany(ctx, []Key{key})
+	if err != nil {
+		return Lookup{}, err
+	}
+	return lookups[0], nil
+}
+
+// GetMany returns the translations of the keys with a single MGET and counts
+// the hits, which also returns the detected source languages, in the same
+// round-trip. With sliding expiration, which MGET cannot apply, and in a
+// cluster, where MGET requires all keys in the same slot, the keys are read
+// with pipelined GETs instead.
+func (c *redisCache) GetMany(ctx context.Context, keys []Key) ([]Lookup, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	storageKeys := make([]string, len(keys))
+	for i, key := range keys {
+		storageKeys[i] = c.schema.key(key)
+	}
+
+	var (
+		mgetCmd *redis.SliceCmd
+		getCmds = make([]*redis.StringCmd, len(keys))
+		hitCmds = make([]*redis.Cmd, len(keys))
+		now     = time.Now().UTC().Format(time.RFC3339)
+	)
+	pipe := c.client.Pipeline()
+	if _, cluster := c.client.(*redis.ClusterClient); !cluster && !c.slidingExpiration {
+		mgetCmd = pipe.MGet(ctx, storageKeys...)
+	}
+	for i, key := range keys {
+		var slidingTTL time.Duration
+		if mgetCmd == nil {
+			if ttl := c.ttlPolicy.ttl(key.SourceLang, key.TargetLang); c.slidingExpiration && ttl > 0 {
+				getCmds[i] = pipe.GetEx(ctx, storageKeys[i], ttl)
+				slidingTTL = ttl
+			} else {
+				getCmds[i] = pipe.Get(ctx, storageKeys[i])
+			}
+		}
+		// a failure to count the hit does not affect the lookup
+		hitCmds[i] = hitScript.Eval(ctx, pipe, []string{metaKey(storageKeys[i])}, metaFieldHits, metaFieldLastHitAt, now, slidingTTL.Milliseconds(), metaFieldDetectedLang)
+	}
+	// errors of single commands, such as missing keys or a failed hit count,
+	// are handled per command below, whereas a connection error is not set
+	// on the commands and is returned as is
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		var redisErr redis.Error
+		if !errors.As(err, &redisErr) {
+			return nil, err
+		}
+	}
+
+	values := make([]interface{}, len(keys))
+	if mgetCmd != nil {
+		result, err := mgetCmd.Result()
+		if err != nil {
+			return nil, err
+		}
+		values = result
+	} else {
+		for i, cmd := range getCmds {
+			value, err := cmd.Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+	}
+
+	lookups := make([]Lookup, len(keys))
+	for i, value := range values {
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		translation, err := c.codec.decode(encoded)
+		if err != nil {
+			return nil, err
+		}
+		// the detected language is missing for entries written before it
+		// was stored, which is reported like an unknown detection
+		detectedLang, _ := hitCmds[i].Text()
+		lookups[i] = Lookup{
+			Translation:        translation,
+			DetectedSourceLang: detectedLang,
+			Found:              true,
+		}
+	}
+	return lookups, nil
+}
+
+// Inspect returns the entry with its metadata in a single round-trip.
+func (c *redisCache) Inspect(ctx context.Context, key Key) (*Entry, bool, error) {
+	entryKey := c.schema.key(key)
+	parsed, _ := c.schema.parseKey(entryKey)
+
+	entries, err := readEntries(ctx, c.client, c.codec, []string{entryKey}, []storageKey{parsed})
+	if err != nil {
+		return nil, false, err
+	}
+	entry := entries[0]
+	if entry == nil {
+		return nil, false, nil
+	}
+	// entries written before the metadata was introduced lack the source text
+	if entry.Text == "" {
+		entry.Text = key.Input
+	}
+	return entry, true, nil
+}
+
+// Delete removes the entries and their metadata.
+func (c *redisCache) Delete(ctx context.Context, keys ...Key) (int, error) {
+	storageKeys := make([]string, len(keys))
+	for i, key := range keys {
+		storageKeys[i] = c.schema.key(key)
+	}
+	return c.unlink(ctx, storageKeys)
+}
+
+// Invalidate scans the keyspace in batches and removes the matching entries
+// and their metadata.
+func (c *redisCache) Invalidate(ctx context.Context, filter InvalidationFilter) (int, error) {
+	deleted := 0
+	err := scanKeys(ctx, c.client, c.schema.pattern(filter.SourceLang, filter.TargetLang, filter.Provider), func(keys []string) error {
+		entryKeys := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if parsed, ok := c.schema.parseKey(key); ok && filter.matches(parsed) {
+				entryKeys = append(entryKeys, key)
+			}
+		}
+
+		n, err := c.unlink(ctx, entryKeys)
+		deleted += n
+		return err
+	})
+	return deleted, err
+}
+
+// unlink removes the entries and their metadata in a single pipeline. Each key
+// is removed separately, since the keys may belong to different cluster slots.
+func (c *redisCache) unlink(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	pipe := c.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Unlink(ctx, key)
+		pipe.Unlink(ctx, metaKey(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, cmd := range cmds {
+		deleted += int(cmd.Val())
+	}
+	return deleted, nil
+}
+
+// readEntries reads the translations, metadata and expirations of the entries
+// in a single pipeline. Missing entries are returned as nil.
+func readEntries(ctx context.Context, client redis.UniversalClient, codec codec, keys []string, parsed []storageKey) ([]*Entry, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := client.Pipeline()
+	valueCmds := make([]*redis.StringCmd, len(keys))
+	metaCmds := make([]*redis.MapStringStringCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		valueCmds[i] = pipe.Get(ctx, key)
+		metaCmds[i] = pipe.HGetAll(ctx, metaKey(key))
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	entries := make([]*Entry, len(keys))
+	for i := range keys {
+		value, err := valueCmds[i].Result()
+		if err != nil {
+			continue
+		}
+		translation, err := codec.decode(value)
+		if err != nil {
+			return nil, err
+		}
+
+		var expiresAt *time.Time
+		if ttl := ttlCmds[i].Val(); ttl > 0 {
+			expiration := time.Now().Add(ttl).UTC().Truncate(time.Second)
+			expiresAt = &expiration
+		}
+		entries[i] = parseMetadata(parsed[i], metaCmds[i].Val()).entry(translation, expiresAt)
+	}
+	return entries, nil
+}
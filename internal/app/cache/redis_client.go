@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// scanBatchSize is the amount of keys requested per SCAN iteration.
+const scanBatchSize = 1000
+
+// RedisOptions contains the connection settings of the redis backend. The
+// topology is derived from the settings: a sentinel master name selects
+// sentinel, cluster addresses select cluster and otherwise a single node at
+// host and port is used.
+type RedisOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	DB       int
+
+	TLSEnabled            bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	SentinelMasterName string
+	SentinelAddrs      []string
+	SentinelUsername   string
+	SentinelPassword   string
+
+	ClusterAddrs []string
+
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+}
+
+// NewRedisClient creates a redis client matching the configured topology.
+func NewRedisClient(opts RedisOptions) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redis tls config: %w", err)
+	}
+
+	universal := &redis.UniversalOptions{
+		Addrs:            []string{fmt.Sprintf("%s:%d", opts.Host, opts.Port)},
+		Username:         opts.Username,
+		Password:         opts.Password,
+		DB:               opts.DB,
+		TLSConfig:        tlsConfig,
+		MasterName:       opts.SentinelMasterName,
+		SentinelUsername: opts.SentinelUsername,
+		SentinelPassword: opts.SentinelPassword,
+		PoolSize:         opts.PoolSize,
+		MinIdleConns:     opts.MinIdleConns,
+		DialTimeout:      opts.DialTimeout,
+		ReadTimeout:      opts.ReadTimeout,
+		WriteTimeout:     opts.WriteTimeout,
+		PoolTimeout:      opts.PoolTimeout,
+
+		// the deadlines of the contexts are applied to the connections, so
+		// that operations can be bounded tighter than the read timeout
+		ContextTimeoutEnabled: true,
+	}
+
+	// the topology is selected explicitly, since the universal client would
+	// create a single node client for a cluster with a single seed node
+	switch {
+	case opts.SentinelMasterName != "":
+		if len(opts.SentinelAddrs) == 0 {
+			return nil, errors.New("sentinel master name is set without sentinel addresses")
+		}
+		universal.Addrs = opts.SentinelAddrs
+		log.Infof("connecting to redis sentinel master %s via %v", opts.SentinelMasterName, opts.SentinelAddrs)
+		return redis.NewFailoverClient(universal.Failover()), nil
+	case len(opts.ClusterAddrs) > 0:
+		if opts.DB != 0 {
+			return nil, errors.New("redis cluster does only support db 0")
+		}
+		universal.Addrs = opts.ClusterAddrs
+		log.Infof("connecting to redis cluster via %v", opts.ClusterAddrs)
+		return redis.NewClusterClient(universal.Cluster()), nil
+	default:
+		log.Infof("connecting to redis at %s", universal.Addrs[0])
+		return redis.NewClient(universal.Simple()), nil
+	}
+}
+
+// newTLSConfig creates the tls config with the custom ca and client
+// certificate. It returns nil if tls is disabled.
+func newTLSConfig(opts RedisOptions) (*tls.Config, error) {
+	if !opts.TLSEnabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.TLSServerName,
+		InsecureSkipVerify: opts.TLSInsecureSkipVerify,
+	}
+
+	if opts.TLSCAFile != "" {
+		ca, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.TLSCAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// scanKeys iterates over all keys matching the pattern in batches. On a
+// cluster every master node is scanned concurrently, but fn is never called
+// concurrently so it may mutate shared state.
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string, fn func(keys []string) error) error {
+	var mu sync.Mutex
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				mu.Lock()
+				err := fn(keys)
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	}
+
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, client)
+}
@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TierLocal  = "local"
+	TierRemote = "remote"
+)
+
+type TieredOptions struct {
+	// MaxBytes is the memory limit of the in-process tier.
+	MaxBytes int
+
+	// TTL is the expiration of entries in the in-process tier. It is kept
+	// short, since entries invalidated in the remote tier stay visible locally
+	// until they expire.
+	TTL time.Duration
+}
+
+// TierStats contains the lookup counters of a cache tier.
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type tierCounters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// TieredCache is a cache with a size-bounded in-process tier in front of a
+// remote cache.
+type TieredCache interface {
+	Cache
+	Stats() map[string]TierStats
+}
+
+type tieredCache struct {
+	remote    Cache
+	local     *lru[Lookup]
+	localTTL  time.Duration
+	schema    keySchema
+	ttlPolicy ttlPolicy
+	maxEntry  int
+	counters  map[string]*tierCounters
+}
+
+// NewTieredCache wraps the remote cache with an in-process tier. The options
+// must be the ones the remote cache has been created with.
+func NewTieredCache(remote Cache, opts Options, tieredOpts TieredOptions) (TieredCache, error) {
+	ttlPolicy, err := newTTLPolicy(opts.TTL, opts.PairTTLs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tieredCache{
+		remote:    remote,
+		local:     newLRU(tieredOpts.MaxBytes, func(lookup Lookup) int { return len(lookup.Translation) + len(lookup.DetectedSourceLang) }),
+		localTTL:  tieredOpts.TTL,
+		schema:    newKeySchema(opts),
+		ttlPolicy: ttlPolicy,
+		maxEntry:  opts.MaxEntrySize,
+		counters: map[string]*tierCounters{
+			TierLocal:  {},
+			TierRemote: {},
+		},
+	}, nil
+}
+
+// Add adds the entry to both tiers.
+func (t *tieredCache) Add(ctx context.Context, item Item) error {
+	return t.SetMany(ctx, []Item{item})
+}
+
+// SetMany adds the entries to both tiers.
+func (t *tieredCache) SetMany(ctx context.Context, items []Item) error {
+	if err := t.remote.SetMany(ctx, items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if t.maxEntry > 0 && len(item.Key.Input)+len(item.Translation) > t.maxEntry {
+			continue
+		}
+		lookup := Lookup{
+			Translation:        item.Translation,
+			DetectedSourceLang: item.DetectedSourceLang,
+			Found:              true,
+		}
+		t.local.set(t.schema.key(item.Key), lookup, t.ttl(item.Key.SourceLang, item.Key.TargetLang))
+	}
+	return nil
+}
+
+// Get returns the entry from the first tier that contains it. Entries found in
+// the remote tier are added to the in-process tier.
+func (t *tieredCache) Get(ctx context.Context, key Key) (Lookup, error) {
+	lookups, err := t.GetMany(ctx, []Key{key})
+	if err != nil {
+		return Lookup{}, err
+	}
+	return lookups[0], nil
+}
+
+// GetMany returns the entries from the in-process tier and looks up the
+// remaining keys in the remote tier with a single batch.
+func (t *tieredCache) GetMany(ctx context.Context, keys []Key) ([]Lookup, error) {
+	lookups := make([]Lookup, len(keys))
+	missing := make([]int, 0, len(keys))
+	for i, key := range keys {
+		if lookup, ok := t.lookupLocal(t.schema.key(key)); ok {
+			lookups[i] = lookup
+			continue
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return lookups, nil
+	}
+
+	remoteKeys := make([]Key, len(missing))
+	for j, i := range missing {
+		remoteKeys[j] = keys[i]
+	}
+	remoteLookups, err := t.remote.GetMany(ctx, remoteKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		if !remoteLookups[j].Found {
+			t.counters[TierRemote].misses.Add(1)
+			continue
+		}
+		t.counters[TierRemote].hits.Add(1)
+		t.local.set(t.schema.key(keys[i]), remoteLookups[j], t.ttl(keys[i].SourceLang, keys[i].TargetLang))
+		lookups[i] = remoteLookups[j]
+	}
+	return lookups, nil
+}
+
+// Inspect returns the entry from the remote tier, which keeps the metadata.
+// Hits served by the in-process tier are not counted in the metadata.
+func (t *tieredCache) Inspect(ctx context.Context, key Key) (*Entry, bool, error) {
+	return t.remote.Inspect(ctx, key)
+}
+
+// Delete removes the entries from both tiers. The in-process tiers of other
+// replicas keep the entries until they expire.
+func (t *tieredCache) Delete(ctx context.Context, keys ...Key) (int, error) {
+	for _, key := range keys {
+		t.local.delete(t.schema.key(key))
+	}
+	return t.remote.Delete(ctx, keys...)
+}
+
+// Invalidate removes the matching entries from both tiers. The in-process
+// tiers of other replicas keep the entries until they expire.
+func (t *tieredCache) Invalidate(ctx context.Context, filter InvalidationFilter) (int, error) {
+	t.local.deleteMatching(func(key string) bool {
+		parsed, ok := t.schema.parseKey(key)
+		return ok && filter.matches(parsed)
+	})
+	return t.remote.Invalidate(ctx, filter)
+}
+
+// Stats returns the hit and miss counters of each tier.
+func (t *tieredCache) Stats() map[string]TierStats {
+	stats := make(map[string]TierStats, len(t.counters))
+	for tier, counters := range t.counters {
+		stats[tier] = TierStats{
+			Hits:   counters.hits.Load(),
+			Misses: counters.misses.Load(),
+		}
+	}
+	return stats
+}
+
+// lookupLocal returns the entry from the in-process tier and counts the lookup.
+func (t *tieredCache) lookupLocal(storageKey string) (Lookup, bool) {
+	lookup, ok := t.local.get(storageKey)
+	if ok {
+		t.counters[TierLocal].hits.Add(1)
+	} else {
+		t.counters[TierLocal].misses.Add(1)
+	}
+	return lookup, ok
+}
+
+// ttl returns the local ttl, which never exceeds the ttl of the remote entry.
+func (t *tieredCache) ttl(sourceLang string, targetLang string) time.Duration {
+	if remoteTTL := t.ttlPolicy.ttl(sourceLang, targetLang); remoteTTL > 0 && remoteTTL < t.localTTL {
+		return remoteTTL
+	}
+	return t.localTTL
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// maxLineSize is the maximum size of a single line of an import file.
+const maxLineSize = 16 * 1024 * 1024
+
+// Entry is the portable representation of a cache entry.
+type Entry struct {
+	SourceLang  string    `json:"sourceLang"`
+	TargetLang  string    `json:"targetLang"`
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model"`
+	Text        string    `json:"text"`
+	Translation string    `json:"translation"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Hits        int64     `json:"hits,omitempty"`
+
+	// DetectedSourceLang is the source language detected by the provider for
+	// entries without source language.
+	DetectedSourceLang string `json:"detectedSourceLang,omitempty"`
+
+	// LastHitAt is the time of the last hit. Nil means that the entry has not been hit yet.
+	LastHitAt *time.Time `json:"lastHitAt,omitempty"`
+
+	// ExpiresAt is the expiration of the entry. Nil means that the entry does
+	// not expire, it is imported with the configured TTL of its language pair.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// expired checks if the entry is expired at the given time.
+func (e *Entry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+// TransferFilter restricts a transfer to a language pair. Empty languages
+// match every language.
+type TransferFilter struct {
+	SourceLang string
+	TargetLang string
+}
+
+func (f TransferFilter) matches(sourceLang string, targetLang string) bool {
+	return (f.SourceLang == "" || keyPart(f.SourceLang) == keyPart(sourceLang)) &&
+		(f.TargetLang == "" || keyPart(f.TargetLang) == keyPart(targetLang))
+}
+
+// TransferResult contains the outcome of an export or import.
+type TransferResult struct {
+	Transferred int
+	Skipped     int
+}
+
+type Transfer interface {
+	Export(ctx context.Context, w io.Writer, filter TransferFilter) (*TransferResult, error)
+	Import(ctx context.Context, r io.Reader, filter TransferFilter, batchSize int) (*TransferResult, error)
+	Close() error
+}
+
+type transfer struct {
+	client    redis.UniversalClient
+	schema    keySchema
+	codec     codec
+	ttlPolicy ttlPolicy
+}
+
+// NewTransfer creates an exporter and importer for the redis cache described
+// by the options.
+func NewTransfer(opts Options) (Transfer, error) {
+	ttlPolicy, err := newTTLPolicy(opts.TTL, opts.PairTTLs)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewRedisClient(opts.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer{
+		client:    client,
+		schema:    newKeySchema(opts),
+		codec:     newCodec(opts),
+		ttlPolicy: ttlPolicy,
+	}, nil
+}
+
+// Export writes all entries matching the filter as JSON lines. Entries without
+// metadata have been written before the source text was stored and are skipped.
+func (t *transfer) Export(ctx context.Context, w io.Writer, filter TransferFilter) (*TransferResult, error) {
+	result := &TransferResult{}
+	encoder := json.NewEncoder(w)
+
+	err := scanKeys(ctx, t.client, t.schema.pattern(filter.SourceLang, filter.TargetLang, ""), func(keys []string) error {
+		parsed := make([]storageKey, 0, len(keys))
+		entryKeys := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if p, ok := t.schema.parseKey(key); ok {
+				parsed = append(parsed, p)
+				entryKeys = append(entryKeys, key)
+			}
+		}
+
+		entries, err := readEntries(ctx, t.client, t.codec, entryKeys, parsed)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry == nil || entry.Text == "" {
+				// the entry expired in between or has no metadata
+				result.Skipped++
+				continue
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+			result.Transferred++
+		}
+		log.Debugf("exported %d entries, skipped %d entries", result.Transferred, result.Skipped)
+		return nil
+	})
+	return result, err
+}
+
+// Import reads entries from JSON lines and writes the ones matching the
+// filter in batched pipelines. The keys are rebuilt with the current schema,
+// so that entries can be moved between environments.
+func (t *transfer) Import(ctx context.Context, r io.Reader, filter TransferFilter, batchSize int) (*TransferResult, error) {
+	result := &TransferResult{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	batch := make([]Entry, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := t.importBatch(ctx, batch); err != nil {
+			return err
+		}
+		result.Transferred += len(batch)
+		batch = batch[:0]
+		log.Debugf("imported %d entries, skipped %d entries", result.Transferred, result.Skipped)
+		return nil
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return result, fmt.Errorf("invalid entry in line %d: %w", line, err)
+		}
+		if entry.Text == "" || entry.Translation == "" || !filter.matches(entry.SourceLang, entry.TargetLang) {
+			result.Skipped++
+			continue
+		}
+		if entry.expired(time.Now()) {
+			// the entry expired since it has been exported
+			result.Skipped++
+			continue
+		}
+
+		batch = append(batch, entry)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	return result, flush()
+}
+
+// importBatch writes the entries in a single pipeline.
+func (t *transfer) importBatch(ctx context.Context, entries []Entry) error {
+	pipe := t.client.Pipeline()
+	for _, entry := range entries {
+		key := Key{
+			Input:      entry.Text,
+			SourceLang: entry.SourceLang,
+			TargetLang: entry.TargetLang,
+		}
+		storageKey := t.schema.withProvider(entry.Provider, entry.Model).key(key)
+
+		createdAt := entry.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		// entries without expiration get the configured TTL and entries that
+		// expire during the import are kept for a second instead of being
+		// stored without expiration
+		ttl := t.ttlPolicy.ttl(entry.SourceLang, entry.TargetLang)
+		if entry.ExpiresAt != nil {
+			ttl = max(time.Until(*entry.ExpiresAt), time.Second)
+		}
+
+		// the metadata is replaced as a whole, so that no fields of an
+		// existing entry are kept
+		pipe.Set(ctx, storageKey, t.codec.encode(entry.Translation), ttl)
+		pipe.Unlink(ctx, metaKey(storageKey))
+		meta := newMetadata(Item{Key: key, DetectedSourceLang: entry.DetectedSourceLang}, entry.Provider, entry.Model, createdAt)
+		meta.Hits = entry.Hits
+		if entry.LastHitAt != nil {
+			meta.LastHitAt = *entry.LastHitAt
+		}
+		pipe.HSet(ctx, metaKey(storageKey), meta.fields())
+		if ttl > 0 {
+			pipe.Expire(ctx, metaKey(storageKey), ttl)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Close closes the redis client.
+func (t *transfer) Close() error {
+	return t.client.Close()
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// wildcard matches every language in a ttl rule.
+const wildcard = "*"
+
+// ttlRule overrides the global ttl for a language pair.
+type ttlRule struct {
+	sourceLang string
+	targetLang string
+	ttl        time.Duration
+}
+
+// ttlPolicy resolves the ttl of an entry by its language pair.
+type ttlPolicy struct {
+	global time.Duration
+	rules  []ttlRule
+}
+
+// newTTLPolicy parses the ttl rules in the form "source:target=ttl", where
+// either language may be "*".
+func newTTLPolicy(global time.Duration, rules []string) (ttlPolicy, error) {
+	policy := ttlPolicy{
+		global: global,
+		rules:  make([]ttlRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		pair, value, ok := strings.Cut(strings.TrimSpace(rule), "=")
+		sourceLang, targetLang, okPair := strings.Cut(pair, ":")
+		if !ok || !okPair || sourceLang == "" || targetLang == "" {
+			return policy, fmt.Errorf("invalid ttl rule %q, expected the form source:target=ttl", rule)
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid ttl in rule %q: %w", rule, err)
+		}
+		policy.rules = append(policy.rules, ttlRule{
+			sourceLang: strings.ToLower(sourceLang),
+			targetLang: strings.ToLower(targetLang),
+			ttl:        ttl,
+		})
+	}
+	return policy, nil
+}
+
+// ttl returns the ttl for the language pair. The most specific rule wins: an
+// exact pair before a rule with one wildcard before the global ttl.
+func (p ttlPolicy) ttl(sourceLang string, targetLang string) time.Duration {
+	if sourceLang == "" {
+		sourceLang = AutoDetect
+	}
+	sourceLang, targetLang = strings.ToLower(sourceLang), strings.ToLower(targetLang)
+
+	ttl, specificity := p.global, 0
+	for _, rule := range p.rules {
+		if (rule.sourceLang != wildcard && rule.sourceLang != sourceLang) ||
+			(rule.targetLang != wildcard && rule.targetLang != targetLang) {
+			continue
+		}
+		s := 1
+		if rule.sourceLang != wildcard {
+			s++
+		}
+		if rule.targetLang != wildcard {
+			s++
+		}
+		if s > specificity {
+			ttl, specificity = rule.ttl, s
+		}
+	}
+	return ttl
+}
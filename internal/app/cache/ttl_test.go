@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLPolicyTTL(t *testing.T) {
+	policy, err := newTTLPolicy(24*time.Hour, []string{
+		"de:en=1h",
+		"*:fr=2h",
+		"nl:fr=5h",
+		"en:*=3h",
+		"auto:*=4h",
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		sourceLang string
+		targetLang string
+		want       time.Duration
+	}{
+		{"exact pair", "de", "en", time.Hour},
+		{"exact pair case insensitive", "DE", "EN", time.Hour},
+		{"target wildcard", "es", "fr", 2 * time.Hour},
+		{"source wildcard", "en", "de", 3 * time.Hour},
+		{"tie keeps first rule", "en", "fr", 2 * time.Hour},
+		{"exact pair before wildcard", "nl", "fr", 5 * time.Hour},
+		{"detected source", "", "es", 4 * time.Hour},
+		{"global", "nl", "es", 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.ttl(tt.sourceLang, tt.targetLang); got != tt.want {
+				t.Errorf("ttl(%q, %q) = %v, want %v", tt.sourceLang, tt.targetLang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTTLPolicyRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"missing ttl", "de:en"},
+		{"missing target", "de=1h"},
+		{"empty source", ":en=1h"},
+		{"invalid duration", "de:en=soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTTLPolicy(time.Hour, []string{tt.rule}); err == nil {
+				t.Errorf("newTTLPolicy(%q) succeeded, want error", tt.rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/pipeline"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+	translationv1 "github.com/dennishilgert/cloud-computing-2/pkg/api/translation/v1"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+var log = logger.NewLogger("app.grpc")
+
+// shutdownTimeout limits the time to finish the running calls on shutdown,
+// since streams may otherwise keep the server open indefinitely.
+const shutdownTimeout = 10 * time.Second
+
+type Options struct {
+	Port int
+}
+
+type Server interface {
+	Run(ctx context.Context) error
+}
+
+type grpcServer struct {
+	translationv1.UnimplementedTranslationServiceServer
+
+	port       int
+	running    atomic.Bool
+	translator translate.Translator
+	pipeline   pipeline.Pipeline
+	policy     policy.Policy
+}
+
+// NewGrpcServer creates a server that offers the translation service with the
+// same pipeline as the http server.
+func NewGrpcServer(translator translate.Translator, pipeline pipeline.Pipeline, policy policy.Policy, opts Options) Server {
+	return &grpcServer{
+		port:       opts.Port,
+		translator: translator,
+		pipeline:   pipeline,
+		policy:     policy,
+	}
+}
+
+// Run starts the grpc server with the health and reflection services and
+// stops it gracefully once the context is done.
+func (s *grpcServer) Run(ctx context.Context) error {
+	if !s.running.CompareAndSwap(false, true) {
+		return errors.New("grpc server is already running")
+	}
+
+	log.Infof("starting grpc server on port %d", s.port)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	translationv1.RegisterTranslationServiceServer(server, s)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	healthServer.SetServingStatus(translationv1.TranslationService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		if err := server.Serve(listener); err != nil {
+			errCh <- fmt.Errorf("error while serving grpc: %w", err)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// clients stop sending new calls once the health service reports that the
+	// server is not serving anymore
+	healthServer.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Warn("running grpc calls did not finish in time, stopping the server")
+		server.Stop()
+	}
+
+	log.Info("grpc server stopped")
+	return <-errCh
+}
+
+// Translate translates a single text.
+func (s *grpcServer) Translate(ctx context.Context, req *translationv1.TranslateRequest) (*translationv1.TranslateResponse, error) {
+	return s.translate(ctx, req)
+}
+
+// TranslateStream translates the requests of the stream in order. The first
+// failed request ends the stream with its error.
+func (s *grpcServer) TranslateStream(stream translationv1.TranslationService_TranslateStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := s.translate(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// ListLanguages lists the languages that can be translated from or into by the
+// provider and are permitted by the language policy.
+func (s *grpcServer) ListLanguages(ctx context.Context, req *translationv1.ListLanguagesRequest) (*translationv1.ListLanguagesResponse, error) {
+	languages := s.translator.AvailableLanguages().Languages()
+	res := &translationv1.ListLanguagesResponse{
+		Languages: make([]*translationv1.Language, 0, len(languages)),
+	}
+	for _, lang := range languages {
+		source := lang.SupportsSource && s.policy.AllowsSource(lang.IsoCode)
+		target := lang.SupportsTarget && s.policy.AllowsTarget(lang.IsoCode)
+		if !source && !target {
+			continue
+		}
+		res.Languages = append(res.Languages, &translationv1.Language{
+			Code:   lang.IsoCode,
+			Name:   lang.DisplayName,
+			Source: source,
+			Target: target,
+		})
+	}
+	return res, nil
+}
+
+// DetectLanguage detects the language of the text at the provider.
+func (s *grpcServer) DetectLanguage(ctx context.Context, req *translationv1.DetectLanguageRequest) (*translationv1.DetectLanguageResponse, error) {
+	text := strings.TrimSpace(req.GetText())
+	if text == "" {
+		return nil, status.Error(codes.InvalidArgument, "text is required")
+	}
+
+	detection, err := s.translator.DetectLanguage(ctx, text)
+	if err != nil {
+		log.Errorf("failed to detect language: %v", err)
+		return nil, translationError(err)
+	}
+	return &translationv1.DetectLanguageResponse{
+		Language:   detection.Language,
+		Confidence: detection.Confidence,
+	}, nil
+}
+
+// translate validates the request and translates it through the pipeline.
+func (s *grpcServer) translate(ctx context.Context, req *translationv1.TranslateRequest) (*translationv1.TranslateResponse, error) {
+	text := strings.TrimSpace(req.GetText())
+	if text == "" || req.GetTargetLang() == "" {
+		return nil, status.Error(codes.InvalidArgument, "text and target_lang are required")
+	}
+
+	languages := s.translator.AvailableLanguages()
+	if req.GetSourceLang() != "" && !languages.ByIsoCode(req.GetSourceLang()).SupportsSource {
+		return nil, status.Errorf(codes.InvalidArgument, "source language %s is not supported", req.GetSourceLang())
+	}
+	if !languages.ByIsoCode(req.GetTargetLang()).SupportsTarget {
+		return nil, status.Errorf(codes.InvalidArgument, "target language %s is not supported", req.GetTargetLang())
+	}
+
+	result, err := s.pipeline.Translate(ctx, pipeline.Request{
+		Input:      text,
+		SourceLang: req.GetSourceLang(),
+		TargetLang: req.GetTargetLang(),
+	})
+	if errors.Is(err, policy.ErrPolicyViolation) {
+		log.Warnf("rejected translation request: %v", err)
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		log.Errorf("failed to translate text: %v", err)
+		return nil, translationError(err)
+	}
+
+	return &translationv1.TranslateResponse{
+		Translation:      result.Translation,
+		SourceLang:       req.GetSourceLang(),
+		TargetLang:       req.GetTargetLang(),
+		DetectedLanguage: result.DetectedSourceLang,
+		Cached:           result.Cached,
+		Provider:         s.translator.Provider(),
+		Model:            s.translator.Model(),
+	}, nil
+}
+
+// translationError maps a failed upstream call to a grpc status.
+func translationError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
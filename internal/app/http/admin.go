@@ -0,0 +1,138 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// cacheEntry identifies a cache entry by its source text and language pair.
+// An empty source language refers to entries with detected source language.
+type cacheEntry struct {
+	Text       string `json:"text" query:"text"`
+	SourceLang string `json:"sourceLang" query:"sourceLang"`
+	TargetLang string `json:"targetLang" query:"targetLang"`
+}
+
+func (e cacheEntry) key() cache.Key {
+	return cache.Key{
+		Input:      e.Text,
+		SourceLang: e.SourceLang,
+		TargetLang: e.TargetLang,
+	}
+}
+
+type lookupResponse struct {
+	Key   string       `json:"key"`
+	Found bool         `json:"found"`
+	Entry *cache.Entry `json:"entry,omitempty"`
+}
+
+type deleteRequest struct {
+	Entries []cacheEntry `json:"entries"`
+}
+
+type deleteResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// authorizedAdmin checks if the request carries the admin token as bearer
+// token. Without admin token no request is authorized.
+func (a *httpServer) authorizedAdmin(c echo.Context) bool {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || a.adminToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) == 1
+}
+
+// registerAdminRoutes registers the endpoints to inspect and invalidate the
+// cache. They are protected by the admin token sent as bearer token.
+func (a *httpServer) registerAdminRoutes(e *echo.Echo) {
+	admin := e.Group("/admin", middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
+		return subtle.ConstantTimeCompare([]byte(key), []byte(a.adminToken)) == 1, nil
+	}))
+
+	// look up a single entry with its metadata by its source text and language
+	// pair, without counting it as a hit
+	admin.GET("/cache/entries", func(c echo.Context) error {
+		var req cacheEntry
+		if err := c.Bind(&req); err != nil || req.Text == "" || req.TargetLang == "" {
+			return c.String(http.StatusBadRequest, "text and targetLang are required")
+		}
+
+		key := req.key()
+		entry, found, err := a.cache.Inspect(c.Request().Context(), key)
+		if err != nil {
+			log.Errorf("failed to look up cache entry: %s, reason: %v", key, err)
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+
+		status := http.StatusOK
+		if !found {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, lookupResponse{
+			Key:   key.String(),
+			Found: found,
+			Entry: entry,
+		})
+	})
+
+	// delete specific entries
+	admin.DELETE("/cache/entries", func(c echo.Context) error {
+		var req deleteRequest
+		if err := c.Bind(&req); err != nil || len(req.Entries) == 0 {
+			return c.String(http.StatusBadRequest, "at least one entry is required")
+		}
+
+		keys := make([]cache.Key, 0, len(req.Entries))
+		for _, entry := range req.Entries {
+			if entry.Text == "" || entry.TargetLang == "" {
+				return c.String(http.StatusBadRequest, "text and targetLang are required for each entry")
+			}
+			keys = append(keys, entry.key())
+		}
+
+		deleted, err := a.cache.Delete(c.Request().Context(), keys...)
+		if err != nil {
+			log.Errorf("failed to delete cache entries: %v", err)
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+
+		log.Infof("deleted %d of %d requested cache entries", deleted, len(keys))
+		return c.JSON(http.StatusOK, deleteResponse{Deleted: deleted})
+	})
+
+	// invalidate all entries of a language pair or provider
+	admin.DELETE("/cache", func(c echo.Context) error {
+		filter := cache.InvalidationFilter{
+			SourceLang: c.QueryParam("sourceLang"),
+			TargetLang: c.QueryParam("targetLang"),
+			Provider:   c.QueryParam("provider"),
+		}
+		// flushing the whole cache by accident must not be possible
+		if filter.IsEmpty() {
+			return c.String(http.StatusBadRequest, "at least one of sourceLang, targetLang or provider is required")
+		}
+
+		deleted, err := a.cache.Invalidate(c.Request().Context(), filter)
+		if err != nil {
+			// the entries removed so far are reported, since the invalidation
+			// is not atomic
+			log.Errorf("failed to invalidate cache entries after deleting %d entries: %v", deleted, err)
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"deleted": deleted,
+				"error":   err.Error(),
+			})
+		}
+
+		log.Infof("invalidated %d cache entries (source: %q, target: %q, provider: %q)", deleted, filter.SourceLang, filter.TargetLang, filter.Provider)
+		return c.JSON(http.StatusOK, deleteResponse{Deleted: deleted})
+	})
+}
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/pipeline"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/labstack/echo/v4"
+)
+
+// apiPrefix is the path prefix of the versioned json api.
+const apiPrefix = "/api/v1"
+
+// error codes of the json api
+const (
+	errCodeInvalidRequest      = "invalid_request"
+	errCodeUnsupportedLanguage = "unsupported_language"
+	errCodePolicyViolation     = "policy_violation"
+	errCodeForbidden           = "forbidden"
+	errCodeTimeout             = "timeout"
+	errCodeTranslationFailed   = "translation_failed"
+	errCodeInternal            = "internal_error"
+)
+
+// apiError is the envelope of every error returned by the json api.
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// translateOptions contains the optional settings of a translation request.
+type translateOptions struct {
+	// SkipCache translates the text upstream without using the cache. It
+	// causes costs at the provider, so it requires the admin token.
+	SkipCache bool `json:"skipCache"`
+}
+
+// translateRequest is the request of the json api. An empty source language
+// lets the provider detect it.
+type translateRequest struct {
+	Source  string           `json:"source"`
+	Target  string           `json:"target"`
+	Text    string           `json:"text"`
+	Options translateOptions `json:"options"`
+}
+
+type translateResponse struct {
+	Translation      string `json:"translation"`
+	Source           string `json:"source,omitempty"`
+	Target           string `json:"target"`
+	DetectedLanguage string `json:"detectedLanguage,omitempty"`
+	Cached           bool   `json:"cached"`
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+}
+
+type languageCapabilities struct {
+	Source bool `json:"source"`
+	Target bool `json:"target"`
+}
+
+type languageResponse struct {
+	Code         string               `json:"code"`
+	Name         string               `json:"name"`
+	Capabilities languageCapabilities `json:"capabilities"`
+}
+
+type languagesResponse struct {
+	Languages []languageResponse `json:"languages"`
+}
+
+// registerAPIRoutes registers the versioned json api for programmatic clients.
+func (a *httpServer) registerAPIRoutes(e *echo.Echo) {
+	api := e.Group(apiPrefix)
+
+	api.POST("/translate", func(c echo.Context) error {
+		var req translateRequest
+		if err := c.Bind(&req); err != nil {
+			return newAPIError(c, http.StatusBadRequest, errCodeInvalidRequest, "request body must be a json object")
+		}
+		req.Text = strings.TrimSpace(req.Text)
+		if req.Text == "" || req.Target == "" {
+			return newAPIError(c, http.StatusBadRequest, errCodeInvalidRequest, "text and target are required")
+		}
+		if req.Options.SkipCache && !a.authorizedAdmin(c) {
+			return newAPIError(c, http.StatusForbidden, errCodeForbidden, "skipCache requires the admin token")
+		}
+
+		languages := a.translator.AvailableLanguages()
+		if req.Source != "" && !languages.ByIsoCode(req.Source).SupportsSource {
+			return newAPIError(c, http.StatusBadRequest, errCodeUnsupportedLanguage, "source language "+req.Source+" is not supported")
+		}
+		if !languages.ByIsoCode(req.Target).SupportsTarget {
+			return newAPIError(c, http.StatusBadRequest, errCodeUnsupportedLanguage, "target language "+req.Target+" is not supported")
+		}
+
+		result, err := a.pipeline.Translate(c.Request().Context(), pipeline.Request{
+			Input:      req.Text,
+			SourceLang: req.Source,
+			TargetLang: req.Target,
+			SkipCache:  req.Options.SkipCache,
+		})
+		switch {
+		case errors.Is(err, policy.ErrPolicyViolation):
+			log.Warnf("rejected translation request: %v", err)
+			return newAPIError(c, http.StatusForbidden, errCodePolicyViolation, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Errorf("failed to translate text: %v", err)
+			return newAPIError(c, http.StatusGatewayTimeout, errCodeTimeout, err.Error())
+		case err != nil:
+			log.Errorf("failed to translate text: %v", err)
+			return newAPIError(c, http.StatusBadGateway, errCodeTranslationFailed, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, translateResponse{
+			Translation:      result.Translation,
+			Source:           req.Source,
+			Target:           req.Target,
+			DetectedLanguage: result.DetectedSourceLang,
+			Cached:           result.Cached,
+			Provider:         a.translator.Provider(),
+			Model:            a.translator.Model(),
+		})
+	})
+
+	// the capabilities combine the support of the provider with the language
+	// policy, languages that can neither be translated from nor into are omitted
+	api.GET("/languages", func(c echo.Context) error {
+		languages := a.translator.AvailableLanguages().Languages()
+		res := languagesResponse{
+			Languages: make([]languageResponse, 0, len(languages)),
+		}
+		for _, lang := range languages {
+			capabilities := languageCapabilities{
+				Source: lang.SupportsSource && a.policy.AllowsSource(lang.IsoCode),
+				Target: lang.SupportsTarget && a.policy.AllowsTarget(lang.IsoCode),
+			}
+			if !capabilities.Source && !capabilities.Target {
+				continue
+			}
+			res.Languages = append(res.Languages, languageResponse{
+				Code:         lang.IsoCode,
+				Name:         lang.DisplayName,
+				Capabilities: capabilities,
+			})
+		}
+		return c.JSON(http.StatusOK, res)
+	})
+}
+
+// apiErrorHandler returns the errors of the json api, such as unknown routes or
+// panics, in the error envelope and leaves all other errors to next.
+func apiErrorHandler(next echo.HTTPErrorHandler) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed || !strings.HasPrefix(c.Request().URL.Path, apiPrefix+"/") {
+			next(err, c)
+			return
+		}
+
+		status := http.StatusInternalServerError
+		message := http.StatusText(status)
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+			message = http.StatusText(status)
+			if msg, ok := httpErr.Message.(string); ok {
+				message = msg
+			}
+		}
+
+		code := errCodeInternal
+		if status < http.StatusInternalServerError {
+			code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+		}
+		if err := newAPIError(c, status, code, message); err != nil {
+			log.Errorf("failed to send api error: %v", err)
+		}
+	}
+}
+
+// newAPIError sends the error in the envelope of the json api.
+func newAPIError(c echo.Context, status int, code string, message string) error {
+	return c.JSON(status, apiError{
+		Error: apiErrorBody{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
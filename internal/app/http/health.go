@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// checkTimeout limits the duration of a single readiness check, so that an
+// unresponsive dependency does not block the load balancer.
+const checkTimeout = 2 * time.Second
+
+// status values of the health endpoints
+const (
+	statusOK       = "ok"
+	statusFailing  = "failing"
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+)
+
+// Check returns an error if the dependency is not available.
+type Check func(ctx context.Context) error
+
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// registerHealthRoutes registers the liveness and readiness endpoints.
+func (a *httpServer) registerHealthRoutes(e *echo.Echo) {
+	// the process is alive as long as it answers requests
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, checkResult{Status: statusOK})
+	})
+
+	// the server is ready if it is listening and not shutting down. The
+	// dependencies are only reported, since a replica keeps translating
+	// without them (the circuit breaker bypasses the cache) and failing
+	// readiness on a shared dependency would take down every replica at once.
+	e.GET("/readyz", func(c echo.Context) error {
+		gates := map[string]Check{
+			"listener": a.checkListener,
+			"shutdown": a.checkShutdown,
+		}
+		dependencies := map[string]Check{
+			"languages": a.checkLanguages,
+		}
+		for name, check := range a.checks {
+			dependencies[name] = check
+		}
+
+		res := readinessResponse{
+			Status: statusReady,
+			Checks: make(map[string]checkResult, len(gates)+len(dependencies)),
+		}
+		for name, check := range gates {
+			res.Checks[name] = runCheck(c.Request().Context(), check)
+			if res.Checks[name].Status != statusOK {
+				res.Status = statusNotReady
+			}
+		}
+		for name, check := range dependencies {
+			res.Checks[name] = runCheck(c.Request().Context(), check)
+		}
+
+		status := http.StatusOK
+		if res.Status != statusReady {
+			log.Debugf("readiness check failed: %v", res.Checks)
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSON(status, res)
+	})
+}
+
+// runCheck runs the check with a timeout and returns its result.
+func runCheck(ctx context.Context, check Check) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		return checkResult{Status: statusFailing, Error: err.Error()}
+	}
+	return checkResult{Status: statusOK}
+}
+
+func (a *httpServer) checkListener(ctx context.Context) error {
+	select {
+	case <-a.readyCh:
+		return nil
+	default:
+		return errors.New("listener is not bound")
+	}
+}
+
+func (a *httpServer) checkLanguages(ctx context.Context) error {
+	if len(a.translator.AvailableLanguages().Languages()) == 0 {
+		return errors.New("no languages loaded")
+	}
+	return nil
+}
+
+func (a *httpServer) checkShutdown(ctx context.Context) error {
+	if a.shuttingDown.Load() {
+		return errors.New("shutting down")
+	}
+	return nil
+}
@@ -6,11 +6,17 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/metrics"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/pipeline"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/stats"
 	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
 	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
 	"github.com/labstack/echo/v4"
@@ -19,8 +25,28 @@ import (
 
 var log = logger.NewLogger("app.http")
 
+// shutdownTimeout limits the time to finish the running requests on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 type Options struct {
 	Port int
+
+	// ShutdownDelay is the time between failing the readiness check and
+	// closing the listener on shutdown.
+	ShutdownDelay time.Duration
+
+	// Checks are the dependencies reported by the readiness endpoint next to
+	// the available languages. They do not fail the readiness.
+	Checks map[string]Check
+
+	// Metrics records the http requests. Nil disables the metrics.
+	Metrics metrics.Metrics
+
+	// ServeMetrics serves the metrics at /metrics of this server.
+	ServeMetrics bool
+
+	// AdminToken protects the admin endpoints. An empty token disables them.
+	AdminToken string
 }
 
 type Server interface {
@@ -33,16 +59,42 @@ type httpServer struct {
 	readyCh    chan struct{}
 	running    atomic.Bool
 	translator translate.Translator
+	pipeline   pipeline.Pipeline
 	cache      cache.Cache
+	policy     policy.Policy
+	recorder   stats.Recorder
+	adminToken string
+
+	checks        map[string]Check
+	shutdownDelay time.Duration
+	shuttingDown  atomic.Bool
+
+	metrics        metrics.Metrics
+	metricsHandler http.Handler
+	serveMetrics   bool
 }
 
-func NewHttpServer(translator translate.Translator, cache cache.Cache, opts Options) Server {
-	return &httpServer{
+func NewHttpServer(translator translate.Translator, pipeline pipeline.Pipeline, cache cache.Cache, policy policy.Policy, recorder stats.Recorder, opts Options) Server {
+	server := &httpServer{
 		port:       opts.Port,
 		readyCh:    make(chan struct{}),
 		translator: translator,
+		pipeline:   pipeline,
 		cache:      cache,
+		policy:     policy,
+		recorder:   recorder,
+		adminToken: opts.AdminToken,
+
+		checks:        opts.Checks,
+		shutdownDelay: opts.ShutdownDelay,
+
+		metrics:      opts.Metrics,
+		serveMetrics: opts.ServeMetrics,
+	}
+	if opts.Metrics != nil {
+		server.metricsHandler = opts.Metrics.Handler()
 	}
+	return server
 }
 
 type TemplateRenderer struct {
@@ -65,7 +117,13 @@ func (a *httpServer) Run(ctx context.Context) error {
 	e := echo.New()
 	e.HideBanner = true
 
+	// the metrics are recorded outside of the recovery, so that panics are
+	// counted as internal server errors
+	if a.metrics != nil {
+		e.Use(a.metrics.Middleware())
+	}
 	e.Use(middleware.Recover())
+	e.HTTPErrorHandler = apiErrorHandler(e.DefaultHTTPErrorHandler)
 
 	// Initialize the template renderer
 	renderer := &TemplateRenderer{
@@ -73,6 +131,65 @@ func (a *httpServer) Run(ctx context.Context) error {
 	}
 	e.Renderer = renderer
 
+	a.registerRoutes(e, a.adminToken != "", a.metrics != nil && a.serveMetrics)
+
+	// the port is bound before the server is marked as ready, so that no
+	// requests are accepted before the listener exists
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", a.port, err)
+	}
+	e.Listener = listener
+
+	// close ready channel to mark server as listening
+	close(a.readyCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh) // ensure channel is closed to avoid goroutine leak
+
+		if err := e.Start(""); err != nil {
+			if !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("error while starting http server: %w", err)
+			}
+			return
+		}
+		errCh <- nil
+	}()
+
+	// block until the context is done
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// the readiness check fails from now on, so that the load balancer stops
+	// routing requests before the listener is closed
+	a.shuttingDown.Store(true)
+	if a.shutdownDelay > 0 {
+		log.Infof("marked http server as not ready, shutting down in %v", a.shutdownDelay)
+		time.Sleep(a.shutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = e.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-errCh
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// registerRoutes registers all endpoints. The admin and metrics endpoints are
+// only registered if admin and metrics are true.
+func (a *httpServer) registerRoutes(e *echo.Echo, admin bool, metrics bool) {
 	// return the rendered template to the client
 	e.GET("/", func(c echo.Context) error {
 		// Render the index template with any dynamic data (if necessary)
@@ -94,15 +211,27 @@ func (a *httpServer) Run(ctx context.Context) error {
 			excludeSelection, currentSelection = currentSelection, excludeSelection
 		}
 
+		// only offer the languages that are permitted by the language policy
+		languages := a.translator.AvailableLanguages().Filter(func(lang translate.Language) bool {
+			return a.policy.AllowsSource(lang.IsoCode)
+		})
+		if values.Get("element") == "targetLang" {
+			sourceLang := a.translator.AvailableLanguages().ByDisplayName(excludeSelection)
+			languages = a.translator.AvailableLanguages().Filter(func(lang translate.Language) bool {
+				return a.policy.Validate(sourceLang.IsoCode, lang.IsoCode) == nil
+			})
+		}
+
 		var htmlOut strings.Builder
 		// add the current selected language as first and therefore
 		// automatically selected option if it should no be excluded
-		if currentSelection != excludeSelection {
+		// and is permitted by the language policy
+		if currentSelection != excludeSelection && languages.ByDisplayName(currentSelection).IsoCode != "" {
 			htmlOut.WriteString(fmt.Sprintf("<option>%v</option>", currentSelection))
 		}
 
 		// append each available language except the current and excluded language
-		for _, lang := range a.translator.AvailableLanguages().DisplayNames() {
+		for _, lang := range languages.DisplayNames() {
 			if strings.EqualFold(excludeSelection, lang) || strings.EqualFold(currentSelection, lang) {
 				continue
 			}
@@ -122,59 +251,47 @@ func (a *httpServer) Run(ctx context.Context) error {
 			return c.String(http.StatusOK, "")
 		}
 
-		hashedKey, has := a.cache.Has(ctx, inputText, targetLang.IsoCode)
-		log.Infof("checking if translation is cached: %s", hashedKey)
-		if has {
-			log.Infof("retrieving translation from cache: %s", hashedKey)
-			return c.String(http.StatusOK, a.cache.Get(ctx, inputText, targetLang.IsoCode))
+		result, err := a.pipeline.Translate(c.Request().Context(), pipeline.Request{
+			Input:      inputText,
+			SourceLang: sourceLang.IsoCode,
+			TargetLang: targetLang.IsoCode,
+		})
+		if errors.Is(err, policy.ErrPolicyViolation) {
+			log.Warnf("rejected translation request: %v", err)
+			return c.String(http.StatusForbidden, err.Error())
 		}
-
-		log.Infof("retrieving translation from cloud translation api: %s", hashedKey)
-		translated, err := a.translator.Translate(ctx, sourceLang.IsoCode, targetLang.IsoCode, inputText)
 		if err != nil {
 			log.Errorf("failed to translate text: %v", err)
 			return c.String(http.StatusInternalServerError, err.Error())
 		}
-		log.Infof("storing translation in cache: %s", hashedKey)
-		if err := a.cache.Add(ctx, inputText, targetLang.IsoCode, *translated); err != nil {
-			log.Errorf("failed to cache translation: %s, reason: %v", hashedKey, err)
-		}
 
-		return c.String(http.StatusOK, *translated)
+		return c.String(http.StatusOK, result.Translation)
 	})
 
-	// close ready channel to mark server as listening
-	close(a.readyCh)
-
-	errCh := make(chan error, 1)
-	go func() {
-		defer close(errCh) // ensure channel is closed to avoid goroutine leak
-
-		if err := e.Start(fmt.Sprintf(":%d", a.port)); err != nil {
-			if !errors.Is(err, http.ErrServerClosed) {
-				errCh <- fmt.Errorf("error while starting http server: %w", err)
-			}
-			return
+	// return the cache statistics aggregated across all replicas
+	e.GET("/stats", func(c echo.Context) error {
+		snapshot, err := a.recorder.Snapshot(c.Request().Context())
+		if err != nil {
+			log.Errorf("failed to load cache statistics: %v", err)
+			return c.String(http.StatusInternalServerError, err.Error())
 		}
-		errCh <- nil
-	}()
-
-	// block until the context is done
-	<-ctx.Done()
+		return c.JSON(http.StatusOK, snapshot)
+	})
 
-	a.translator.Close()
+	a.registerAPIRoutes(e)
+	a.registerDocsRoutes(e)
+	a.registerHealthRoutes(e)
 
-	err := e.Shutdown(ctx)
-	if err != nil {
-		return err
+	if admin {
+		a.registerAdminRoutes(e)
 	}
 
-	err = <-errCh
-	if err != nil {
-		return err
+	if metrics {
+		e.GET("/metrics", func(c echo.Context) error {
+			a.metricsHandler.ServeHTTP(c.Response(), c.Request())
+			return nil
+		})
 	}
-
-	return nil
 }
 
 // Ready waits until the http server is ready or the context is cancelled due to timeout.
@@ -0,0 +1,30 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openAPISpec is the hand-maintained OpenAPI document of all endpoints.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders the OpenAPI document without external assets, so that the
+// documentation is available offline.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// registerDocsRoutes registers the OpenAPI document and the documentation page.
+func (a *httpServer) registerDocsRoutes(e *echo.Echo) {
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openAPISpec)
+	})
+
+	e.GET("/docs", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, docsPage)
+	})
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// specMethods are the operations of an OpenAPI path item.
+var specMethods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodTrace,
+}
+
+// pathParamPattern matches the path parameters of echo routes.
+var pathParamPattern = regexp.MustCompile(`:([^/]+)`)
+
+func TestOpenAPISpecIsValidJSON(t *testing.T) {
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("failed to parse openapi spec: %v", err)
+	}
+	if spec["openapi"] == nil || spec["paths"] == nil {
+		t.Fatal("openapi spec lacks the openapi version or the paths")
+	}
+}
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	server := NewHttpServer(nil, nil, nil, nil, nil, Options{}).(*httpServer)
+	if err := server.verifyOpenAPI(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// verifyOpenAPI registers all endpoints, including the optional ones, on a
+// separate router and returns an error if they differ from the operations of
+// the OpenAPI document, so that the spec cannot silently diverge from the
+// routes.
+func (a *httpServer) verifyOpenAPI() error {
+	e := echo.New()
+	a.registerRoutes(e, true, true)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("failed to parse openapi spec: %w", err)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range spec.Paths {
+		for method := range item {
+			method = strings.ToUpper(method)
+			if slices.Contains(specMethods, method) {
+				documented[method+" "+path] = true
+			}
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, route := range e.Routes() {
+		// routes added by echo itself, such as the not found handlers of
+		// groups, have no http method
+		if !slices.Contains(specMethods, route.Method) {
+			continue
+		}
+		registered[route.Method+" "+pathParamPattern.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+
+	var undocumented, unregistered []string
+	for operation := range registered {
+		if !documented[operation] {
+			undocumented = append(undocumented, operation)
+		}
+	}
+	for operation := range documented {
+		if !registered[operation] {
+			unregistered = append(unregistered, operation)
+		}
+	}
+	if len(undocumented) == 0 && len(unregistered) == 0 {
+		return nil
+	}
+
+	slices.Sort(undocumented)
+	slices.Sort(unregistered)
+	return fmt.Errorf("openapi spec diverges from the registered routes, undocumented: %v, not registered: %v", undocumented, unregistered)
+}
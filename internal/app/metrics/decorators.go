@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/stats"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+)
+
+type translator struct {
+	translate.Translator
+	metrics Metrics
+}
+
+// NewTranslator wraps a translator so that the calls of the translation
+// provider are measured.
+func NewTranslator(next translate.Translator, metrics Metrics) translate.Translator {
+	return &translator{
+		Translator: next,
+		metrics:    metrics,
+	}
+}
+
+// Translate records the call, its latency and the characters sent upstream.
+func (t *translator) Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*translate.Translation, error) {
+	t.metrics.UpstreamChars(sourceLang, targetLang, utf8.RuneCountInString(input))
+
+	start := time.Now()
+	translation, err := t.Translator.Translate(ctx, sourceLang, targetLang, input)
+	t.metrics.Upstream(OperationTranslate, time.Since(start), err)
+	return translation, err
+}
+
+// DetectLanguage records the call and its latency.
+func (t *translator) DetectLanguage(ctx context.Context, input string) (*translate.Detection, error) {
+	start := time.Now()
+	detection, err := t.Translator.DetectLanguage(ctx, input)
+	t.metrics.Upstream(OperationDetect, time.Since(start), err)
+	return detection, err
+}
+
+type recorder struct {
+	stats.Recorder
+	metrics Metrics
+}
+
+// NewRecorder wraps a statistics recorder so that the cache hits, misses and
+// errors are also exposed as metrics of this replica.
+func NewRecorder(next stats.Recorder, metrics Metrics) stats.Recorder {
+	return &recorder{
+		Recorder: next,
+		metrics:  metrics,
+	}
+}
+
+func (r *recorder) Hit(sourceLang string, targetLang string, chars int) {
+	r.metrics.CacheHit(sourceLang, targetLang)
+	r.Recorder.Hit(sourceLang, targetLang, chars)
+}
+
+func (r *recorder) Miss(sourceLang string, targetLang string, chars int) {
+	r.metrics.CacheMiss(sourceLang, targetLang)
+	r.Recorder.Miss(sourceLang, targetLang, chars)
+}
+
+func (r *recorder) CacheError(sourceLang string, targetLang string) {
+	r.metrics.CacheError(sourceLang, targetLang)
+	r.Recorder.CacheError(sourceLang, targetLang)
+}
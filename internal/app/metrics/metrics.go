@@ -0,0 +1,291 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var log = logger.NewLogger("app.metrics")
+
+// namespace prefixes the names of all metrics.
+const namespace = "translator"
+
+// routeUnmatched labels requests that did not match any route, so that
+// arbitrary paths do not create new series.
+const routeUnmatched = "unmatched"
+
+// upstream operations
+const (
+	OperationTranslate = "translate"
+	OperationDetect    = "detect"
+)
+
+// Metrics collects the metrics of the app and exposes them in the Prometheus
+// text format.
+type Metrics interface {
+	// Handler serves the metrics.
+	Handler() http.Handler
+
+	// Middleware records the count and latency of the http requests.
+	Middleware() echo.MiddlewareFunc
+
+	CacheHit(sourceLang string, targetLang string)
+	CacheMiss(sourceLang string, targetLang string)
+	CacheError(sourceLang string, targetLang string)
+
+	// Upstream records a call of the translation provider.
+	Upstream(operation string, duration time.Duration, err error)
+
+	// UpstreamChars records the characters sent to the translation provider.
+	UpstreamChars(sourceLang string, targetLang string, chars int)
+
+	// RegisterCacheTiers exposes the lookup counters of each tier of the cache.
+	RegisterCacheTiers(tiered cache.TieredCache)
+
+	// RegisterCacheBreaker exposes the state of the circuit breaker of the cache.
+	RegisterCacheBreaker(breaker cache.BreakerCache)
+}
+
+type metrics struct {
+	registry         *prometheus.Registry
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	cacheHits        *prometheus.CounterVec
+	cacheMisses      *prometheus.CounterVec
+	cacheErrors      *prometheus.CounterVec
+	upstreamRequests *prometheus.CounterVec
+	upstreamErrors   *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+	upstreamChars    *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics in a separate registry next to the go runtime
+// and process metrics.
+func NewMetrics() Metrics {
+	pairLabels := []string{"source_lang", "target_lang"}
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of http requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of http requests by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of translations served from the cache by language pair.",
+		}, pairLabels),
+		cacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of translations missing in the cache by language pair.",
+		}, pairLabels),
+		cacheErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_errors_total",
+			Help:      "Number of failed cache operations by language pair.",
+		}, pairLabels),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Number of calls of the translation provider by operation.",
+		}, []string{"operation"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_errors_total",
+			Help:      "Number of failed calls of the translation provider by operation.",
+		}, []string{"operation"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of calls of the translation provider by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		upstreamChars: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_characters_total",
+			Help:      "Number of characters sent to the translation provider by language pair.",
+		}, pairLabels),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.cacheHits,
+		m.cacheMisses,
+		m.cacheErrors,
+		m.upstreamRequests,
+		m.upstreamErrors,
+		m.upstreamDuration,
+		m.upstreamChars,
+	)
+	return m
+}
+
+// Handler serves the metrics of the registry.
+func (m *metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog: errorLogger{},
+	})
+}
+
+// Middleware records the requests by their route pattern instead of the path,
+// so that the number of series is bounded.
+func (m *metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			// the error is turned into a response by the error handler after
+			// the middleware returns, so the status is taken from the error
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = routeUnmatched
+			}
+
+			labels := prometheus.Labels{
+				"route":  route,
+				"method": c.Request().Method,
+				"status": strconv.Itoa(status),
+			}
+			m.httpRequests.With(labels).Inc()
+			m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+func (m *metrics) CacheHit(sourceLang string, targetLang string) {
+	m.cacheHits.WithLabelValues(pairLabelValues(sourceLang, targetLang)...).Inc()
+}
+
+func (m *metrics) CacheMiss(sourceLang string, targetLang string) {
+	m.cacheMisses.WithLabelValues(pairLabelValues(sourceLang, targetLang)...).Inc()
+}
+
+func (m *metrics) CacheError(sourceLang string, targetLang string) {
+	m.cacheErrors.WithLabelValues(pairLabelValues(sourceLang, targetLang)...).Inc()
+}
+
+func (m *metrics) Upstream(operation string, duration time.Duration, err error) {
+	m.upstreamRequests.WithLabelValues(operation).Inc()
+	m.upstreamDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		m.upstreamErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *metrics) UpstreamChars(sourceLang string, targetLang string, chars int) {
+	m.upstreamChars.WithLabelValues(pairLabelValues(sourceLang, targetLang)...).Add(float64(chars))
+}
+
+// RegisterCacheTiers registers a collector that reads the counters of the
+// tiers on each scrape, so that the cache does not depend on the metrics.
+func (m *metrics) RegisterCacheTiers(tiered cache.TieredCache) {
+	m.registry.MustRegister(&tierCollector{
+		cache: tiered,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "cache_tier_lookups_total"),
+			"Number of cache lookups by tier and result.",
+			[]string{"tier", "result"}, nil,
+		),
+	})
+}
+
+// tierCollector exposes the lookup counters of the tiers of a tiered cache.
+type tierCollector struct {
+	cache cache.TieredCache
+	desc  *prometheus.Desc
+}
+
+func (c *tierCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *tierCollector) Collect(ch chan<- prometheus.Metric) {
+	for tier, stats := range c.cache.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(stats.Hits), tier, "hit")
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(stats.Misses), tier, "miss")
+	}
+}
+
+// RegisterCacheBreaker registers a collector that reads the state of the
+// breaker on each scrape.
+func (m *metrics) RegisterCacheBreaker(breaker cache.BreakerCache) {
+	m.registry.MustRegister(&breakerCollector{
+		breaker: breaker,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "cache_breaker_state"),
+			"State of the circuit breaker of the cache, 1 for the current state.",
+			[]string{"state"}, nil,
+		),
+	})
+}
+
+// breakerStates are the states reported by the breaker collector.
+var breakerStates = []string{cache.BreakerClosed, cache.BreakerOpen, cache.BreakerHalfOpen}
+
+// breakerCollector exposes the state of a circuit breaker as one series per
+// state, so that alerts can match the open state directly.
+type breakerCollector struct {
+	breaker cache.BreakerCache
+	desc    *prometheus.Desc
+}
+
+func (c *breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	current := c.breaker.State()
+	for _, state := range breakerStates {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, state)
+	}
+}
+
+// errorLogger logs the errors of the metrics handler.
+type errorLogger struct{}
+
+func (errorLogger) Println(v ...interface{}) {
+	log.Errorf("failed to serve metrics: %s", fmt.Sprint(v...))
+}
+
+// pairLabelValues labels a detected source language as "auto".
+func pairLabelValues(sourceLang string, targetLang string) []string {
+	if sourceLang == "" {
+		sourceLang = "auto"
+	}
+	return []string{sourceLang, targetLang}
+}
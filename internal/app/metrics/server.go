@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout limits the time to finish a running scrape on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+type ServerOptions struct {
+	Port int
+}
+
+type Server interface {
+	Run(ctx context.Context) error
+}
+
+type server struct {
+	port    int
+	metrics Metrics
+}
+
+// NewServer creates a server that serves only the metrics on a separate port,
+// so that they are not exposed next to the public endpoints.
+func NewServer(metrics Metrics, opts ServerOptions) Server {
+	return &server{
+		port:    opts.Port,
+		metrics: metrics,
+	}
+}
+
+// Run serves the metrics at /metrics until the context is done.
+func (s *server) Run(ctx context.Context) error {
+	log.Infof("starting metrics server on port %d", s.port)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.Handler())
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("error while serving metrics: %w", err)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-errCh
+}
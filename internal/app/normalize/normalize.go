@@ -0,0 +1,125 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// pipelineVersion must be increased whenever the behaviour of an existing
+// normalization step changes, so that keys of older entries are not misread.
+const pipelineVersion = 1
+
+const (
+	UnicodeNone = "none"
+	UnicodeNFC  = "nfc"
+	UnicodeNFKC = "nfkc"
+)
+
+// quoteReplacer unifies typographic quotes with their ASCII counterparts.
+var quoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", "\"", "”", "\"", "„", "\"", "‟", "\"", "″", "\"",
+	"«", "\"", "»", "\"",
+)
+
+type Options struct {
+	// Unicode defines the unicode normalization form (none, nfc or nfkc).
+	Unicode string
+
+	// CollapseWhitespace collapses runs of whitespace into a single space.
+	CollapseWhitespace bool
+
+	// CaseFoldMaxLength enables case folding for inputs with at most this
+	// amount of characters. Zero disables case folding.
+	CaseFoldMaxLength int
+
+	// UnifyQuotes replaces typographic quotes with ASCII quotes.
+	UnifyQuotes bool
+}
+
+// Normalizer normalizes inputs before they are used to build cache keys. It
+// must never be used to alter the text that is sent to the translation provider.
+type Normalizer interface {
+	Normalize(input string) string
+	Version() string
+}
+
+type step func(input string) string
+
+type normalizer struct {
+	steps   []step
+	version string
+}
+
+// NewNormalizer creates a normalization pipeline from the given options.
+func NewNormalizer(opts Options) (Normalizer, error) {
+	steps := []step{}
+	version := []string{fmt.Sprintf("n%d", pipelineVersion)}
+
+	switch strings.ToLower(opts.Unicode) {
+	case "", UnicodeNone:
+	case UnicodeNFC:
+		steps = append(steps, norm.NFC.String)
+		version = append(version, UnicodeNFC)
+	case UnicodeNFKC:
+		steps = append(steps, norm.NFKC.String)
+		version = append(version, UnicodeNFKC)
+	default:
+		return nil, fmt.Errorf("unsupported unicode normalization form: %s", opts.Unicode)
+	}
+
+	if opts.UnifyQuotes {
+		steps = append(steps, quoteReplacer.Replace)
+		version = append(version, "q")
+	}
+
+	if opts.CollapseWhitespace {
+		steps = append(steps, collapseWhitespace)
+		version = append(version, "ws")
+	}
+
+	if opts.CaseFoldMaxLength > 0 {
+		steps = append(steps, caseFold(opts.CaseFoldMaxLength))
+		version = append(version, fmt.Sprintf("cf%d", opts.CaseFoldMaxLength))
+	}
+
+	return &normalizer{
+		steps:   steps,
+		version: strings.Join(version, "."),
+	}, nil
+}
+
+// Normalize runs the input through all steps of the pipeline.
+func (n *normalizer) Normalize(input string) string {
+	for _, step := range n.steps {
+		input = step(input)
+	}
+	return input
+}
+
+// Version returns an identifier of the pipeline that changes whenever the
+// normalized output for the same input may change.
+func (n *normalizer) Version() string {
+	return n.version
+}
+
+// collapseWhitespace replaces each run of whitespace with a single space.
+func collapseWhitespace(input string) string {
+	return strings.Join(strings.FieldsFunc(input, unicode.IsSpace), " ")
+}
+
+// caseFold folds the case of inputs that are not longer than maxLength characters.
+func caseFold(maxLength int) step {
+	return func(input string) string {
+		if utf8.RuneCountInString(input) > maxLength {
+			return input
+		}
+		// a caser is stateful and must not be shared between goroutines
+		return cases.Fold().String(input)
+	}
+}
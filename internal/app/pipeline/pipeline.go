@@ -0,0 +1,326 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/cache"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/normalize"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/policy"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/stats"
+	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+var log = logger.NewLogger("app.pipeline")
+
+type Options struct {
+	// UpstreamTimeout limits the duration of a coalesced upstream call, which
+	// is detached from the context of the request that started it. Zero
+	// disables the deadline.
+	UpstreamTimeout time.Duration
+
+	// LockWait is the time to wait for the translation of another replica
+	// that holds the lock of the key before translating directly.
+	LockWait time.Duration
+
+	// LockPollInterval defines how often the cache is checked while waiting.
+	LockPollInterval time.Duration
+
+	// MaxEntrySize is the maximum size of cache entries in bytes. Inputs that
+	// exceed it on their own cannot be cached, so they are translated without
+	// acquiring the lock. Zero disables the limit.
+	MaxEntrySize int
+}
+
+// Request is a request to translate the input from the source into the target
+// language.
+type Request struct {
+	Input      string
+	SourceLang string
+	TargetLang string
+
+	// SkipCache translates the input upstream without looking it up in or
+	// storing it into the cache.
+	SkipCache bool
+}
+
+// Result is the outcome of a translation request.
+type Result struct {
+	Translation string
+
+	// DetectedSourceLang is the source language detected by the provider. It
+	// is only known if the source language has been omitted and the input has
+	// been translated upstream.
+	DetectedSourceLang string
+
+	// Cached is true if the translation has been served from the cache.
+	Cached bool
+
+	// Shared is true if the translation has been shared with concurrent
+	// identical requests.
+	Shared bool
+}
+
+// Pipeline translates texts by validating the request against the language
+// policy, looking it up in the cache and translating it upstream on a miss.
+type Pipeline interface {
+	Translate(ctx context.Context, req Request) (*Result, error)
+}
+
+type pipeline struct {
+	translator       translate.Translator
+	cache            cache.Cache
+	locker           cache.Locker
+	policy           policy.Policy
+	normalizer       normalize.Normalizer
+	recorder         stats.Recorder
+	upstreamTimeout  time.Duration
+	lockWait         time.Duration
+	lockPollInterval time.Duration
+	maxEntrySize     int
+	flights          singleflight.Group
+}
+
+// flightResult is the result shared by the requests of a flight.
+type flightResult struct {
+	translation        string
+	detectedSourceLang string
+	cached             bool
+}
+
+func NewPipeline(translator translate.Translator, cache cache.Cache, locker cache.Locker, policy policy.Policy, normalizer normalize.Normalizer, recorder stats.Recorder, opts Options) Pipeline {
+	return &pipeline{
+		translator:       translator,
+		cache:            cache,
+		locker:           locker,
+		policy:           policy,
+		normalizer:       normalizer,
+		recorder:         recorder,
+		upstreamTimeout:  opts.UpstreamTimeout,
+		lockWait:         opts.LockWait,
+		lockPollInterval: opts.LockPollInterval,
+		maxEntrySize:     opts.MaxEntrySize,
+	}
+}
+
+// Translate returns the translation of the request. Concurrent identical
+// requests that miss the cache share a single upstream call and cache write.
+func (p *pipeline) Translate(ctx context.Context, req Request) (*Result, error) {
+	if err := p.policy.Validate(req.SourceLang, req.TargetLang); err != nil {
+		return nil, err
+	}
+
+	key := cache.Key{
+		Input:      req.Input,
+		SourceLang: req.SourceLang,
+		TargetLang: req.TargetLang,
+	}
+
+	// a cache outage must not break translations, so the request is
+	// served from the cloud translation api and nothing is cached
+	var (
+		cached   cache.Lookup
+		cacheErr error
+	)
+	if !req.SkipCache {
+		cached, cacheErr = p.cache.Get(ctx, key)
+	}
+	// entries of detected source languages stored without the detected
+	// language cannot be checked against the language policy, so they are
+	// translated again and replaced
+	hit := cached.Found && (req.SourceLang != "" || cached.DetectedSourceLang != "")
+	if errors.Is(cacheErr, cache.ErrCircuitOpen) {
+		// the outage has already been logged when the breaker opened
+		log.Debugf("cache circuit breaker is open, bypassing cache: %s", key)
+		p.recorder.CacheError(req.SourceLang, req.TargetLang)
+	} else if cacheErr != nil {
+		log.Warnf("cache is unavailable, bypassing it: %s, reason: %v", key, cacheErr)
+		p.recorder.CacheError(req.SourceLang, req.TargetLang)
+	} else if hit {
+		if err := p.validateDetected(req, cached.DetectedSourceLang); err != nil {
+			return nil, err
+		}
+		log.Infof("retrieving translation from cache: %s", key)
+		p.recorder.Hit(req.SourceLang, req.TargetLang, utf8.RuneCountInString(req.Input))
+		return &Result{
+			Translation:        cached.Translation,
+			DetectedSourceLang: cached.DetectedSourceLang,
+			Cached:             true,
+		}, nil
+	}
+
+	// the upstream call must not be cancelled if the request that started it
+	// ends, since other requests may still wait for its result
+	flightCh := p.flights.DoChan(p.flightKey(req), func() (interface{}, error) {
+		flightCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+		if p.upstreamTimeout > 0 {
+			flightCtx, cancel = context.WithTimeout(flightCtx, p.upstreamTimeout)
+		}
+		defer cancel()
+		return p.translate(flightCtx, key, cacheErr == nil && !req.SkipCache && p.cacheable(req.Input))
+	})
+
+	select {
+	case res := <-flightCh:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		if res.Shared {
+			log.Infof("shared upstream translation with concurrent requests: %s", key)
+		}
+		flight := res.Val.(*flightResult)
+		if err := p.validateDetected(req, flight.detectedSourceLang); err != nil {
+			return nil, err
+		}
+		return &Result{
+			Translation:        flight.translation,
+			DetectedSourceLang: flight.detectedSourceLang,
+			Cached:             flight.cached,
+			Shared:             res.Shared,
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// validateDetected checks the detected source language of a request without
+// source language against the language policy, since the request itself has
+// only been validated as auto-detection.
+func (p *pipeline) validateDetected(req Request, detectedSourceLang string) error {
+	if req.SourceLang != "" || detectedSourceLang == "" {
+		return nil
+	}
+	return p.policy.Validate(detectedSourceLang, req.TargetLang)
+}
+
+// validateDetection detects the source language of an input without source
+// language and checks it against the language policy, so that translations in
+// a language that is not permitted are rejected before they are requested.
+func (p *pipeline) validateDetection(ctx context.Context, key cache.Key) error {
+	if key.SourceLang != "" || !p.policy.RestrictsDetected() {
+		return nil
+	}
+
+	detection, err := p.translator.DetectLanguage(ctx, key.Input)
+	if err != nil {
+		p.recorder.UpstreamError(key.SourceLang, key.TargetLang)
+		return fmt.Errorf("failed to detect source language: %w", err)
+	}
+	return p.policy.Validate(detection.Language, key.TargetLang)
+}
+
+// cacheable checks if the input alone does not exceed the maximum entry size.
+// Larger entries are skipped by the cache, so waiting for the lock of such an
+// entry would only delay the request.
+func (p *pipeline) cacheable(input string) bool {
+	return p.maxEntrySize <= 0 || len(input) <= p.maxEntrySize
+}
+
+// translate translates the text upstream and stores the translation in the
+// cache. If another replica holds the lock of the key, its translation is
+// awaited in the cache instead.
+func (p *pipeline) translate(ctx context.Context, key cache.Key, store bool) (*flightResult, error) {
+	if err := p.validateDetection(ctx, key); err != nil {
+		return nil, err
+	}
+
+	if store {
+		release, acquired, err := p.locker.Lock(ctx, key)
+		switch {
+		case errors.Is(err, cache.ErrCircuitOpen):
+			log.Debugf("cache circuit breaker is open, translating without lock: %s", key)
+		case err != nil:
+			log.Warnf("failed to acquire translation lock, translating without it: %s, reason: %v", key, err)
+		case acquired:
+			defer release()
+		default:
+			if lookup, ok := p.awaitTranslation(ctx, key); ok {
+				return &flightResult{
+					translation:        lookup.Translation,
+					detectedSourceLang: lookup.DetectedSourceLang,
+					cached:             true,
+				}, nil
+			}
+			log.Infof("translation of another replica did not arrive in time, translating directly: %s", key)
+		}
+	}
+
+	log.Infof("retrieving translation from cloud translation api: %s", key)
+	p.recorder.Miss(key.SourceLang, key.TargetLang, utf8.RuneCountInString(key.Input))
+	translated, err := p.translator.Translate(ctx, key.SourceLang, key.TargetLang, key.Input)
+	if err != nil {
+		p.recorder.UpstreamError(key.SourceLang, key.TargetLang)
+		return nil, fmt.Errorf("failed to translate text: %w", err)
+	}
+
+	if store {
+		log.Infof("storing translation in cache: %s", key)
+		item := cache.Item{
+			Key:                key,
+			Translation:        translated.Text,
+			DetectedSourceLang: translated.DetectedSourceLang,
+		}
+		if err := p.cache.Add(ctx, item); err != nil {
+			log.Errorf("failed to cache translation: %s, reason: %v", key, err)
+			p.recorder.CacheError(key.SourceLang, key.TargetLang)
+		}
+	}
+	return &flightResult{
+		translation:        translated.Text,
+		detectedSourceLang: translated.DetectedSourceLang,
+	}, nil
+}
+
+// awaitTranslation polls the cache until the translation of the replica
+// holding the lock arrives or the wait times out.
+func (p *pipeline) awaitTranslation(ctx context.Context, key cache.Key) (cache.Lookup, bool) {
+	if p.lockWait <= 0 || p.lockPollInterval <= 0 {
+		return cache.Lookup{}, false
+	}
+
+	log.Infof("waiting for translation of another replica: %s", key)
+	timeout := time.NewTimer(p.lockWait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(p.lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lookup, err := p.cache.Get(ctx, key)
+			if err != nil {
+				return cache.Lookup{}, false
+			}
+			if lookup.Found {
+				p.recorder.Hit(key.SourceLang, key.TargetLang, utf8.RuneCountInString(key.Input))
+				return lookup, true
+			}
+		case <-timeout.C:
+			return cache.Lookup{}, false
+		case <-ctx.Done():
+			return cache.Lookup{}, false
+		}
+	}
+}
+
+// flightKey identifies identical requests by their languages, the translation
+// options and the normalized input. Requests skipping the cache do not share
+// a flight with requests using it.
+func (p *pipeline) flightKey(req Request) string {
+	return strings.Join([]string{
+		strconv.FormatBool(req.SkipCache),
+		req.SourceLang,
+		req.TargetLang,
+		p.translator.Provider(),
+		p.translator.Model(),
+		p.normalizer.Version(),
+		p.normalizer.Normalize(req.Input),
+	}, "\x00")
+}
@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// wildcard matches every language code.
+const wildcard = "*"
+
+// AutoDetect is the source language code of requests without source language,
+// whose source language is detected by the provider. Source allow lists must
+// contain it to permit the detection.
+const AutoDetect = "auto"
+
+// ErrPolicyViolation is wrapped by every error returned due to a policy rule.
+var ErrPolicyViolation = errors.New("language policy violation")
+
+// Options contains the allow and deny rules of the language policy. Language
+// rules are language codes like "de" or "zh-CN", where "de" also matches
+// regional variants like "de-AT". Pair rules have the form "source:target" and
+// accept "*" on either side. Empty allow lists allow everything and deny rules
+// always take precedence over allow rules. The detection of the source language
+// is permitted by the source rules with the code "auto", the detected language
+// is checked against all rules once it is known.
+type Options struct {
+	SourceAllow []string
+	SourceDeny  []string
+	TargetAllow []string
+	TargetDeny  []string
+	PairAllow   []string
+	PairDeny    []string
+}
+
+type Policy interface {
+	AllowsSource(code string) bool
+	AllowsTarget(code string) bool
+	Validate(sourceCode string, targetCode string) error
+
+	// RestrictsDetected checks if a detected source language may be rejected,
+	// so that it has to be detected before the input is translated.
+	RestrictsDetected() bool
+}
+
+type pair struct {
+	source string
+	target string
+}
+
+type policy struct {
+	sourceAllow []string
+	sourceDeny  []string
+	targetAllow []string
+	targetDeny  []string
+	pairAllow   []pair
+	pairDeny    []pair
+}
+
+// NewPolicy creates a language policy from the given rules.
+func NewPolicy(opts Options) (Policy, error) {
+	pairAllow, err := parsePairs(opts.PairAllow)
+	if err != nil {
+		return nil, err
+	}
+	pairDeny, err := parsePairs(opts.PairDeny)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy{
+		sourceAllow: normalizeCodes(opts.SourceAllow),
+		sourceDeny:  normalizeCodes(opts.SourceDeny),
+		targetAllow: normalizeCodes(opts.TargetAllow),
+		targetDeny:  normalizeCodes(opts.TargetDeny),
+		pairAllow:   pairAllow,
+		pairDeny:    pairDeny,
+	}, nil
+}
+
+// AllowsSource checks if the language may be used as source language.
+func (p *policy) AllowsSource(code string) bool {
+	return allowed(p.sourceAllow, p.sourceDeny, code)
+}
+
+// AllowsTarget checks if the language may be used as target language.
+func (p *policy) AllowsTarget(code string) bool {
+	return allowed(p.targetAllow, p.targetDeny, code)
+}
+
+// Validate checks if a translation from the source into the target language is
+// permitted and returns an error wrapping `ErrPolicyViolation` if not. An empty
+// source language is checked as "auto" against the source and target rules
+// only, since the pair rules apply to the detected language.
+func (p *policy) Validate(sourceCode string, targetCode string) error {
+	if sourceCode == "" {
+		if !p.AllowsSource(AutoDetect) {
+			return fmt.Errorf("%w: detection of the source language is not permitted", ErrPolicyViolation)
+		}
+		if !p.AllowsTarget(targetCode) {
+			return fmt.Errorf("%w: target language %q is not permitted", ErrPolicyViolation, targetCode)
+		}
+		return nil
+	}
+	if !p.AllowsSource(sourceCode) {
+		return fmt.Errorf("%w: source language %q is not permitted", ErrPolicyViolation, sourceCode)
+	}
+	if !p.AllowsTarget(targetCode) {
+		return fmt.Errorf("%w: target language %q is not permitted", ErrPolicyViolation, targetCode)
+	}
+	for _, rule := range p.pairDeny {
+		if rule.matches(sourceCode, targetCode) {
+			return fmt.Errorf("%w: translation from %q into %q is not permitted", ErrPolicyViolation, sourceCode, targetCode)
+		}
+	}
+	if len(p.pairAllow) == 0 {
+		return nil
+	}
+	for _, rule := range p.pairAllow {
+		if rule.matches(sourceCode, targetCode) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: translation from %q into %q is not permitted", ErrPolicyViolation, sourceCode, targetCode)
+}
+
+// RestrictsDetected checks if there are source or pair rules that a detected
+// source language may violate.
+func (p *policy) RestrictsDetected() bool {
+	return len(p.sourceAllow) > 0 || len(p.sourceDeny) > 0 || len(p.pairAllow) > 0 || len(p.pairDeny) > 0
+}
+
+func (p pair) matches(sourceCode string, targetCode string) bool {
+	return matchesCode(p.source, sourceCode) && matchesCode(p.target, targetCode)
+}
+
+// allowed checks a language code against an allow and a deny list.
+func allowed(allow []string, deny []string, code string) bool {
+	for _, rule := range deny {
+		if matchesCode(rule, code) {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, rule := range allow {
+		if matchesCode(rule, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesCode checks if the rule matches the language code. A rule without
+// region also matches all regional variants of the language.
+func matchesCode(rule string, code string) bool {
+	code = strings.ToLower(code)
+	return rule == wildcard || rule == code || strings.HasPrefix(code, rule+"-")
+}
+
+// parsePairs parses pair rules in the form "source:target".
+func parsePairs(rules []string) ([]pair, error) {
+	pairs := make([]pair, 0, len(rules))
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		source, target, ok := strings.Cut(strings.TrimSpace(rule), ":")
+		if !ok || source == "" || target == "" {
+			return nil, fmt.Errorf("invalid language pair rule %q, expected the form source:target", rule)
+		}
+		pairs = append(pairs, pair{
+			source: strings.ToLower(source),
+			target: strings.ToLower(target),
+		})
+	}
+	return pairs, nil
+}
+
+func normalizeCodes(codes []string) []string {
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code = strings.ToLower(strings.TrimSpace(code)); code != "" {
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		sourceCode string
+		targetCode string
+		want       bool
+	}{
+		{"empty policy", Options{}, "de", "en", true},
+		{"source allowed", Options{SourceAllow: []string{"de"}}, "de", "en", true},
+		{"source not allowed", Options{SourceAllow: []string{"de"}}, "fr", "en", false},
+		{"regional source allowed by language", Options{SourceAllow: []string{"de"}}, "de-AT", "en", true},
+		{"language not allowed by regional rule", Options{SourceAllow: []string{"de-AT"}}, "de", "en", false},
+		{"source denied", Options{SourceDeny: []string{"ru"}}, "ru", "en", false},
+		{"deny before allow", Options{SourceAllow: []string{"*"}, SourceDeny: []string{"ru"}}, "ru", "en", false},
+		{"codes case insensitive", Options{SourceDeny: []string{"ZH-cn"}}, "zh-CN", "en", false},
+		{"target not allowed", Options{TargetAllow: []string{"en"}}, "de", "fr", false},
+		{"target denied", Options{TargetDeny: []string{"fr"}}, "de", "fr", false},
+		{"pair allowed", Options{PairAllow: []string{"de:en"}}, "de", "en", true},
+		{"pair not allowed", Options{PairAllow: []string{"de:en"}}, "en", "de", false},
+		{"pair wildcard", Options{PairAllow: []string{"*:en"}}, "fr", "en", true},
+		{"pair denied", Options{PairDeny: []string{"de:*"}}, "de", "fr", false},
+		{"detection allowed", Options{}, "", "en", true},
+		{"detection not allowed", Options{SourceAllow: []string{"de"}}, "", "en", false},
+		{"detection allowed explicitly", Options{SourceAllow: []string{"de", AutoDetect}}, "", "en", true},
+		{"detection denied", Options{SourceDeny: []string{AutoDetect}}, "", "en", false},
+		{"detection skips pair rules", Options{PairAllow: []string{"de:en"}}, "", "en", true},
+		{"detection checks target", Options{TargetDeny: []string{"en"}}, "", "en", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = p.Validate(tt.sourceCode, tt.targetCode)
+			if got := err == nil; got != tt.want {
+				t.Errorf("Validate(%q, %q) = %v, want allowed %v", tt.sourceCode, tt.targetCode, err, tt.want)
+			}
+			if err != nil && !errors.Is(err, ErrPolicyViolation) {
+				t.Errorf("Validate(%q, %q) = %v, want ErrPolicyViolation", tt.sourceCode, tt.targetCode, err)
+			}
+		})
+	}
+}
+
+func TestPolicyRestrictsDetected(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want bool
+	}{
+		{"empty policy", Options{}, false},
+		{"target rules only", Options{TargetAllow: []string{"en"}, TargetDeny: []string{"fr"}}, false},
+		{"source allow", Options{SourceAllow: []string{AutoDetect, "de"}}, true},
+		{"source deny", Options{SourceDeny: []string{"ru"}}, true},
+		{"pair allow", Options{PairAllow: []string{"de:en"}}, true},
+		{"pair deny", Options{PairDeny: []string{"ru:*"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.RestrictsDetected(); got != tt.want {
+				t.Errorf("RestrictsDetected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicyRejectsInvalidPairs(t *testing.T) {
+	tests := []string{"de", "de:", ":en", "de-en"}
+	for _, rule := range tests {
+		t.Run(rule, func(t *testing.T) {
+			if _, err := NewPolicy(Options{PairAllow: []string{rule}}); err == nil {
+				t.Errorf("NewPolicy(%q) succeeded, want error", rule)
+			}
+		})
+	}
+}
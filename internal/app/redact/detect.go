@@ -0,0 +1,246 @@
+package redact
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	EntityEmail      = "email"
+	EntityPhone      = "phone"
+	EntityIban       = "iban"
+	EntityCreditCard = "creditcard"
+)
+
+const (
+	defaultEmailPattern = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`
+
+	// defaultPhonePattern only matches international numbers starting with
+	// "+" or "00" and national numbers whose area code is separated from the
+	// subscriber number, so that plain numbers like IDs are not redacted.
+	// Digit groups are never joined across line breaks.
+	defaultPhonePattern = `(?:\+|\b00)\d[\d \t().\-/]{5,}\d|(?:\(0\d{1,5}\)[ \t]?|\b0\d{1,5}[ \t\-/])\d[\d \t\-]{3,}\d`
+
+	// defaultIbanPattern may run into the following words, the match is
+	// trimmed to the longest prefix with a valid checksum.
+	defaultIbanPattern       = `(?i)\b[A-Z]{2}\d{2}(?:[ \t]?[A-Z0-9]){11,30}\b`
+	defaultCreditCardPattern = `\b\d(?:[ \-]?\d){12,18}\b`
+)
+
+// datePattern matches numeric dates with an optional time, which resemble
+// phone numbers with grouped digits.
+var datePattern = regexp.MustCompile(`\b(?:\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2})?)?|\d{1,2}[./\-]\d{1,2}[./\-]\d{2,4})\b`)
+
+// detector finds one kind of sensitive entity inside a text.
+type detector struct {
+	entity  string
+	pattern *regexp.Regexp
+
+	// extent returns the length of the valid entity at the start of the
+	// match, or zero if there is none. Nil accepts the whole match.
+	extent func(match string) int
+
+	// exclude skips matches overlapping one of its matches.
+	exclude *regexp.Regexp
+}
+
+// newDetector creates a detector for the given entity. An empty pattern falls
+// back to the built-in pattern of the entity.
+func newDetector(entity string, pattern string) (*detector, error) {
+	var (
+		extent  func(string) int
+		exclude *regexp.Regexp
+	)
+	switch entity {
+	case EntityEmail:
+		pattern = orDefault(pattern, defaultEmailPattern)
+	case EntityPhone:
+		pattern = orDefault(pattern, defaultPhonePattern)
+		extent = phoneExtent
+		exclude = datePattern
+	case EntityIban:
+		pattern = orDefault(pattern, defaultIbanPattern)
+		extent = ibanExtent
+	case EntityCreditCard:
+		pattern = orDefault(pattern, defaultCreditCardPattern)
+		extent = whole(validLuhn)
+	default:
+		return nil, &UnknownEntityError{Entity: entity}
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &detector{
+		entity:  entity,
+		pattern: compiled,
+		extent:  extent,
+		exclude: exclude,
+	}, nil
+}
+
+// UnknownEntityError is returned if an entity is configured that has no detector.
+type UnknownEntityError struct {
+	Entity string
+}
+
+func (e *UnknownEntityError) Error() string {
+	return "unknown redaction entity: " + e.Entity
+}
+
+// matches returns the index pairs of all valid matches in the input.
+func (d *detector) matches(input string) [][]int {
+	var excluded [][]int
+	if d.exclude != nil {
+		excluded = d.exclude.FindAllStringIndex(input, -1)
+	}
+
+	var valid [][]int
+	for _, loc := range d.pattern.FindAllStringIndex(input, -1) {
+		if d.extent != nil {
+			n := d.extent(input[loc[0]:loc[1]])
+			if n == 0 {
+				continue
+			}
+			loc[1] = loc[0] + n
+		}
+		if overlaps(excluded, loc) {
+			continue
+		}
+		valid = append(valid, loc)
+	}
+	return valid
+}
+
+// overlaps checks if the index pair overlaps one of the index pairs.
+func overlaps(locs [][]int, loc []int) bool {
+	for _, other := range locs {
+		if loc[0] < other[1] && other[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// whole accepts the whole match if it is valid.
+func whole(valid func(match string) bool) func(string) int {
+	return func(match string) int {
+		if valid(match) {
+			return len(match)
+		}
+		return 0
+	}
+}
+
+// phoneExtent returns the length of the longest prefix of the match ending
+// with a complete digit group that contains a plausible amount of digits for
+// a phone number according to E.164.
+func phoneExtent(match string) int {
+	for end := len(match); end > 0; end-- {
+		if !isDigit(match[end-1]) || (end < len(match) && isDigit(match[end])) {
+			continue
+		}
+		n := len(digits(match[:end]))
+		if n < 7 {
+			break
+		}
+		if n <= 15 {
+			return end
+		}
+	}
+	return 0
+}
+
+// ibanExtent returns the length of the longest prefix of the match ending
+// with a complete group that is a valid IBAN.
+func ibanExtent(match string) int {
+	for end := len(match); end > 0; end-- {
+		if end < len(match) && !isBlank(match[end]) || isBlank(match[end-1]) {
+			continue
+		}
+		if validIban(match[:end]) {
+			return end
+		}
+	}
+	return 0
+}
+
+// validIban validates the ISO 13616 mod-97 checksum of an IBAN.
+func validIban(match string) bool {
+	iban := strings.ToUpper(strings.NewReplacer(" ", "", "\t", "").Replace(match))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// move the country code and check digits to the end and replace
+	// each letter with its numeric value (A = 10, ..., Z = 35)
+	var numeric strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			numeric.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			numeric.WriteString(strconv.Itoa(int(r - 'A' + 10)))
+		default:
+			return false
+		}
+	}
+
+	value, ok := new(big.Int).SetString(numeric.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
+
+// validLuhn validates the Luhn checksum of a credit card number.
+func validLuhn(match string) bool {
+	number := digits(match)
+	if len(number) < 13 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// digits strips every character except digits from the input.
+func digits(input string) string {
+	var out strings.Builder
+	for _, r := range input {
+		if r >= '0' && r <= '9' {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func orDefault(value string, defaultVal string) string {
+	if value == "" {
+		return defaultVal
+	}
+	return value
+}
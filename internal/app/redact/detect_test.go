@@ -0,0 +1,97 @@
+package redact
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidLuhn(t *testing.T) {
+	tests := []struct {
+		name  string
+		match string
+		want  bool
+	}{
+		{"visa", "4111111111111111", true},
+		{"visa with spaces", "4111 1111 1111 1111", true},
+		{"mastercard with dashes", "5500-0000-0000-0004", true},
+		{"amex", "378282246310005", true},
+		{"wrong checksum", "4111111111111112", false},
+		{"too short", "79927398713", false},
+		{"too long", "41111111111111111111", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validLuhn(tt.match); got != tt.want {
+				t.Errorf("validLuhn(%q) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidIban(t *testing.T) {
+	tests := []struct {
+		name  string
+		match string
+		want  bool
+	}{
+		{"german", "DE89370400440532013000", true},
+		{"german grouped", "DE89 3704 0044 0532 0130 00", true},
+		{"lowercase", "de89370400440532013000", true},
+		{"british", "GB82WEST12345698765432", true},
+		{"wrong checksum", "DE89370400440532013001", false},
+		{"too short", "DE8937040044", false},
+		{"invalid character", "DE89-3704-0044-0532-0130-00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validIban(tt.match); got != tt.want {
+				t.Errorf("validIban(%q) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectorMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		input  string
+		want   []string
+	}{
+		{"international phone", EntityPhone, "Call +49 30 1234567 now", []string{"+49 30 1234567"}},
+		{"international phone with 00", EntityPhone, "Call 0049 30 1234567 now", []string{"0049 30 1234567"}},
+		{"national phone with area code", EntityPhone, "Call 030 1234567 now", []string{"030 1234567"}},
+		{"national phone with parentheses", EntityPhone, "Call (030) 123-4567 now", []string{"(030) 123-4567"}},
+		{"national phone with slash", EntityPhone, "Call 0171/1234567 now", []string{"0171/1234567"}},
+		{"iso date", EntityPhone, "Due on 2024-01-15", nil},
+		{"iso date with time", EntityPhone, "Due on 2024-01-15 10:30", nil},
+		{"numeric date", EntityPhone, "Due on 01-15-2024", nil},
+		{"plain number", EntityPhone, "Order 12345678", nil},
+		{"phone followed by line", EntityPhone, "Call +49 30 1234567\n2024 was a good year", []string{"+49 30 1234567"}},
+		{"phone followed by number", EntityPhone, "Call +49 30 1234567 12345678", []string{"+49 30 1234567"}},
+		{"grouped iban", EntityIban, "Pay to DE89 3704 0044 0532 0130 00.", []string{"DE89 3704 0044 0532 0130 00"}},
+		{"lowercase iban", EntityIban, "Pay to de89370400440532013000.", []string{"de89370400440532013000"}},
+		{"invalid iban", EntityIban, "Pay to DE89370400440532013001.", nil},
+		{"iban followed by word", EntityIban, "Pay to DE89370400440532013000 bitte", []string{"DE89370400440532013000"}},
+		{"grouped iban followed by word", EntityIban, "Pay to DE89 3704 0044 0532 0130 00 now", []string{"DE89 3704 0044 0532 0130 00"}},
+		{"grouped iban followed by group", EntityIban, "Pay to BE68 5390 0754 7034 dann", []string{"BE68 5390 0754 7034"}},
+		{"iban followed by line", EntityIban, "Pay to DE89370400440532013000\nthanks", []string{"DE89370400440532013000"}},
+		{"credit card", EntityCreditCard, "Card 4111 1111 1111 1111 expires", []string{"4111 1111 1111 1111"}},
+		{"invalid credit card", EntityCreditCard, "Card 4111 1111 1111 1112 expires", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := newDetector(tt.entity, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, loc := range d.matches(tt.input) {
+				got = append(got, tt.input[loc[0]:loc[1]])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matches(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/translate"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+)
+
+var log = logger.NewLogger("app.redact")
+
+// placeholderPattern matches the placeholders inserted by the redactor. Spaces
+// and casing are tolerated because translation providers tend to alter them.
+var placeholderPattern = regexp.MustCompile(`(?i)\[\[\s*([a-z]+)_(\d+)\s*\]\]`)
+
+type Options struct {
+	Enabled           bool
+	Entities          []string
+	EmailPattern      string
+	PhonePattern      string
+	IbanPattern       string
+	CreditCardPattern string
+}
+
+type Redactor interface {
+	Redact(input string) *Redaction
+	Counts() map[string]uint64
+}
+
+type redactor struct {
+	detectors []*detector
+	counts    map[string]uint64
+	lock      sync.Mutex
+}
+
+// NewRedactor creates a redactor with a detector for each configured entity.
+func NewRedactor(opts Options) (Redactor, error) {
+	patterns := map[string]string{
+		EntityEmail:      opts.EmailPattern,
+		EntityPhone:      opts.PhonePattern,
+		EntityIban:       opts.IbanPattern,
+		EntityCreditCard: opts.CreditCardPattern,
+	}
+
+	for _, entity := range opts.Entities {
+		if _, ok := patterns[strings.ToLower(strings.TrimSpace(entity))]; !ok {
+			return nil, &UnknownEntityError{Entity: entity}
+		}
+	}
+
+	// the detectors run from the most to the least specific entity, so that
+	// e.g. a credit card number is not redacted as phone number
+	detectors := []*detector{}
+	for _, entity := range []string{EntityEmail, EntityIban, EntityCreditCard, EntityPhone} {
+		if !containsEntity(opts.Entities, entity) {
+			continue
+		}
+		d, err := newDetector(entity, patterns[entity])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create detector for %s: %w", entity, err)
+		}
+		detectors = append(detectors, d)
+	}
+
+	return &redactor{
+		detectors: detectors,
+		counts:    map[string]uint64{},
+	}, nil
+}
+
+// Redact replaces every detected entity with a placeholder. Equal values are
+// replaced with the same placeholder.
+func (r *redactor) Redact(input string) *Redaction {
+	redaction := &Redaction{
+		Text:    input,
+		Counts:  map[string]int{},
+		values:  map[string]string{},
+		byValue: map[string]string{},
+	}
+
+	for _, d := range r.detectors {
+		locs := d.matches(redaction.Text)
+		if len(locs) == 0 {
+			continue
+		}
+
+		var out strings.Builder
+		last := 0
+		for _, loc := range locs {
+			out.WriteString(redaction.Text[last:loc[0]])
+			out.WriteString(redaction.placeholder(d.entity, redaction.Text[loc[0]:loc[1]]))
+			last = loc[1]
+		}
+		out.WriteString(redaction.Text[last:])
+		redaction.Text = out.String()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for entity, count := range redaction.Counts {
+		r.counts[entity] += uint64(count)
+	}
+
+	return redaction
+}
+
+// Counts returns the number of redacted entities since startup by entity.
+func (r *redactor) Counts() map[string]uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	counts := make(map[string]uint64, len(r.counts))
+	for entity, count := range r.counts {
+		counts[entity] = count
+	}
+	return counts
+}
+
+// Redaction is the result of redacting a single text.
+type Redaction struct {
+	// Text is the input with all detected entities replaced by placeholders.
+	Text string
+
+	// Counts contains the number of redacted entities by entity.
+	Counts map[string]int
+
+	values  map[string]string // placeholder -> original value
+	byValue map[string]string // original value -> placeholder
+}
+
+// Total returns the number of redacted entities.
+func (r *Redaction) Total() int {
+	total := 0
+	for _, count := range r.Counts {
+		total += count
+	}
+	return total
+}
+
+// Restore replaces the placeholders in the translated text with the original values.
+func (r *Redaction) Restore(translated string) (string, int) {
+	restored := 0
+	out := placeholderPattern.ReplaceAllStringFunc(translated, func(match string) string {
+		groups := placeholderPattern.FindStringSubmatch(match)
+		value, ok := r.values[placeholderName(groups[1], groups[2])]
+		if !ok {
+			return match
+		}
+		restored++
+		return value
+	})
+	return out, restored
+}
+
+// placeholder returns the placeholder for the value and registers it if the
+// value has not been seen before.
+func (r *Redaction) placeholder(entity string, value string) string {
+	if p, ok := r.byValue[value]; ok {
+		return p
+	}
+	r.Counts[entity]++
+	name := placeholderName(entity, fmt.Sprint(len(r.values)+1))
+	r.values[name] = value
+	r.byValue[value] = "[[" + name + "]]"
+	return r.byValue[value]
+}
+
+func placeholderName(entity string, index string) string {
+	return strings.ToUpper(entity) + "_" + index
+}
+
+func containsEntity(entities []string, entity string) bool {
+	for _, e := range entities {
+		if strings.EqualFold(strings.TrimSpace(e), entity) {
+			return true
+		}
+	}
+	return false
+}
+
+type translator struct {
+	translate.Translator
+	redactor Redactor
+}
+
+// NewTranslator wraps a translator so that sensitive entities are redacted
+// before the text is sent to the translation provider.
+func NewTranslator(next translate.Translator, redactor Redactor) translate.Translator {
+	return &translator{
+		Translator: next,
+		redactor:   redactor,
+	}
+}
+
+// Translate redacts the input, translates it and restores the original values
+// into the translation.
+func (t *translator) Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*translate.Translation, error) {
+	redaction := t.redactor.Redact(input)
+	if redaction.Total() == 0 {
+		return t.Translator.Translate(ctx, sourceLang, targetLang, input)
+	}
+
+	fields := make(map[string]any, len(redaction.Counts))
+	for entity, count := range redaction.Counts {
+		fields[entity] = count
+	}
+	log.WithFields(fields).Infof("redacted %d sensitive entities before translation", redaction.Total())
+
+	translated, err := t.Translator.Translate(ctx, sourceLang, targetLang, redaction.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, count := redaction.Restore(translated.Text)
+	if count < redaction.Total() {
+		log.Warnf("restored only %d of %d redacted entities, the provider altered placeholders", count, redaction.Total())
+	}
+	translated.Text = restored
+	return translated, nil
+}
+
+// DetectLanguage redacts the input before the language is detected, since the
+// provider must not receive the sensitive entities either.
+func (t *translator) DetectLanguage(ctx context.Context, input string) (*translate.Detection, error) {
+	return t.Translator.DetectLanguage(ctx, t.redactor.Redact(input).Text)
+}
+
+// Close logs the redaction counts since startup and closes the wrapped translator.
+func (t *translator) Close() {
+	counts := t.redactor.Counts()
+	fields := make(map[string]any, len(counts))
+	for entity, count := range counts {
+		fields[entity] = count
+	}
+	log.WithFields(fields).Info("redaction summary since startup")
+
+	t.Translator.Close()
+}
@@ -0,0 +1,38 @@
+package redact
+
+import "testing"
+
+func TestRestore(t *testing.T) {
+	redactor, err := NewRedactor(Options{
+		Enabled:  true,
+		Entities: []string{EntityEmail, EntityPhone, EntityIban, EntityCreditCard},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redaction := redactor.Redact("Mail max@example.com or call +49 30 1234567, max@example.com again.")
+	if want := "Mail [[EMAIL_1]] or call [[PHONE_2]], [[EMAIL_1]] again."; redaction.Text != want {
+		t.Fatalf("Redact() = %q, want %q", redaction.Text, want)
+	}
+
+	tests := []struct {
+		name       string
+		translated string
+		want       string
+		restored   int
+	}{
+		{"unchanged placeholders", "Schreib [[EMAIL_1]] oder ruf [[PHONE_2]] an, nochmal [[EMAIL_1]].", "Schreib max@example.com oder ruf +49 30 1234567 an, nochmal max@example.com.", 3},
+		{"altered casing and spaces", "Schreib [[ email_1 ]] oder ruf [[Phone_2]] an.", "Schreib max@example.com oder ruf +49 30 1234567 an.", 2},
+		{"reordered placeholders", "[[PHONE_2]] / [[EMAIL_1]]", "+49 30 1234567 / max@example.com", 2},
+		{"unknown placeholder", "Schreib [[EMAIL_3]] an.", "Schreib [[EMAIL_3]] an.", 0},
+		{"dropped placeholders", "Schreib mir.", "Schreib mir.", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, restored := redaction.Restore(tt.translated)
+			if got != tt.want || restored != tt.restored {
+				t.Errorf("Restore(%q) = %q, %d, want %q, %d", tt.translated, got, restored, tt.want, tt.restored)
+			}
+		})
+	}
+}
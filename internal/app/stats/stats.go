@@ -0,0 +1,305 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+)
+
+var log = logger.NewLogger("app.stats")
+
+type Options struct {
+	// FlushInterval defines how often the counters are written to the store.
+	FlushInterval time.Duration
+
+	// SummaryInterval defines how often a summary is logged. Zero disables the summary.
+	SummaryInterval time.Duration
+}
+
+// Counters contains the cache and upstream counters of a language pair.
+type Counters struct {
+	Hits           uint64 `json:"hits"`
+	Misses         uint64 `json:"misses"`
+	CacheErrors    uint64 `json:"cacheErrors"`
+	UpstreamErrors uint64 `json:"upstreamErrors"`
+	CharsSaved     uint64 `json:"charsSaved"`
+	CharsUpstream  uint64 `json:"charsUpstream"`
+
+	// BytesRaw and BytesStored are the sizes of the cached translations
+	// before and after compression.
+	BytesRaw    uint64 `json:"bytesRaw"`
+	BytesStored uint64 `json:"bytesStored"`
+}
+
+// add adds the counters of other to the counters.
+func (c *Counters) add(other Counters) {
+	c.Hits += other.Hits
+	c.Misses += other.Misses
+	c.CacheErrors += other.CacheErrors
+	c.UpstreamErrors += other.UpstreamErrors
+	c.CharsSaved += other.CharsSaved
+	c.CharsUpstream += other.CharsUpstream
+	c.BytesRaw += other.BytesRaw
+	c.BytesStored += other.BytesStored
+}
+
+// HitRatio returns the share of lookups that have been served from the cache.
+func (c Counters) HitRatio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// CompressionRatio returns the ratio of the raw to the stored size of the
+// cached translations.
+func (c Counters) CompressionRatio() float64 {
+	if c.BytesStored == 0 {
+		return 0
+	}
+	return float64(c.BytesRaw) / float64(c.BytesStored)
+}
+
+// Pair identifies a language pair.
+type Pair struct {
+	SourceLang string `json:"sourceLang"`
+	TargetLang string `json:"targetLang"`
+}
+
+// PairStats contains the counters of a language pair.
+type PairStats struct {
+	Pair
+	Counters
+	HitRatio         float64 `json:"hitRatio"`
+	CompressionRatio float64 `json:"compressionRatio"`
+}
+
+// Snapshot contains the counters aggregated across all replicas.
+type Snapshot struct {
+	Total PairStats   `json:"total"`
+	Pairs []PairStats `json:"pairs"`
+}
+
+// Store persists the counters, so that they are aggregated across replicas.
+type Store interface {
+	Increment(ctx context.Context, deltas map[Pair]Counters) error
+	Load(ctx context.Context) (map[Pair]Counters, error)
+}
+
+type Recorder interface {
+	// Hit records a cache hit that saved an upstream call for chars characters.
+	Hit(sourceLang string, targetLang string, chars int)
+
+	// Miss records a cache miss that sent chars characters upstream.
+	Miss(sourceLang string, targetLang string, chars int)
+
+	// CacheError records a failed cache operation.
+	CacheError(sourceLang string, targetLang string)
+
+	// UpstreamError records a failed upstream translation.
+	UpstreamError(sourceLang string, targetLang string)
+
+	// Stored records the size of a cached translation before and after compression.
+	Stored(sourceLang string, targetLang string, rawBytes int, storedBytes int)
+
+	// Snapshot returns the counters of all replicas.
+	Snapshot(ctx context.Context) (*Snapshot, error)
+
+	// Run periodically flushes the counters to the store until the context is done.
+	Run(ctx context.Context) error
+}
+
+type recorder struct {
+	store           Store
+	flushInterval   time.Duration
+	summaryInterval time.Duration
+	deltas          map[Pair]*Counters
+	lock            sync.Mutex
+}
+
+// NewRecorder creates a recorder that collects the counters in memory and
+// periodically flushes them to the store.
+func NewRecorder(store Store, opts Options) Recorder {
+	return &recorder{
+		store:           store,
+		flushInterval:   opts.FlushInterval,
+		summaryInterval: opts.SummaryInterval,
+		deltas:          map[Pair]*Counters{},
+	}
+}
+
+// Hit records a cache hit that saved an upstream call for chars characters.
+func (r *recorder) Hit(sourceLang string, targetLang string, chars int) {
+	r.record(sourceLang, targetLang, func(c *Counters) {
+		c.Hits++
+		c.CharsSaved += uint64(chars)
+	})
+}
+
+// Miss records a cache miss that sent chars characters upstream.
+func (r *recorder) Miss(sourceLang string, targetLang string, chars int) {
+	r.record(sourceLang, targetLang, func(c *Counters) {
+		c.Misses++
+		c.CharsUpstream += uint64(chars)
+	})
+}
+
+// CacheError records a failed cache operation.
+func (r *recorder) CacheError(sourceLang string, targetLang string) {
+	r.record(sourceLang, targetLang, func(c *Counters) {
+		c.CacheErrors++
+	})
+}
+
+// UpstreamError records a failed upstream translation.
+func (r *recorder) UpstreamError(sourceLang string, targetLang string) {
+	r.record(sourceLang, targetLang, func(c *Counters) {
+		c.UpstreamErrors++
+	})
+}
+
+// Stored records the size of a cached translation before and after compression.
+func (r *recorder) Stored(sourceLang string, targetLang string, rawBytes int, storedBytes int) {
+	r.record(sourceLang, targetLang, func(c *Counters) {
+		c.BytesRaw += uint64(rawBytes)
+		c.BytesStored += uint64(storedBytes)
+	})
+}
+
+func (r *recorder) record(sourceLang string, targetLang string, update func(c *Counters)) {
+	pair := Pair{
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	counters, ok := r.deltas[pair]
+	if !ok {
+		counters = &Counters{}
+		r.deltas[pair] = counters
+	}
+	update(counters)
+}
+
+// Snapshot returns the counters of all replicas. Counters that have not been
+// flushed yet are included for the own replica.
+func (r *recorder) Snapshot(ctx context.Context) (*Snapshot, error) {
+	stored, err := r.store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	for pair, delta := range r.deltas {
+		counters := stored[pair]
+		counters.add(*delta)
+		stored[pair] = counters
+	}
+	r.lock.Unlock()
+
+	snapshot := &Snapshot{
+		Pairs: make([]PairStats, 0, len(stored)),
+	}
+	for pair, counters := range stored {
+		snapshot.Total.Counters.add(counters)
+		snapshot.Pairs = append(snapshot.Pairs, PairStats{
+			Pair:             pair,
+			Counters:         counters,
+			HitRatio:         counters.HitRatio(),
+			CompressionRatio: counters.CompressionRatio(),
+		})
+	}
+	snapshot.Total.HitRatio = snapshot.Total.Counters.HitRatio()
+	snapshot.Total.CompressionRatio = snapshot.Total.Counters.CompressionRatio()
+
+	// the pairs that save the most characters come first
+	sort.Slice(snapshot.Pairs, func(i, j int) bool {
+		return snapshot.Pairs[i].CharsSaved > snapshot.Pairs[j].CharsSaved
+	})
+	return snapshot, nil
+}
+
+// Run periodically flushes the counters to the store and logs a summary until
+// the context is done. The remaining counters are flushed on shutdown.
+func (r *recorder) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(r.flushInterval)
+	defer flushTicker.Stop()
+
+	var summaryCh <-chan time.Time
+	if r.summaryInterval > 0 {
+		summaryTicker := time.NewTicker(r.summaryInterval)
+		defer summaryTicker.Stop()
+		summaryCh = summaryTicker.C
+	}
+
+	for {
+		select {
+		case <-flushTicker.C:
+			r.flush(ctx)
+		case <-summaryCh:
+			r.logSummary(ctx)
+		case <-ctx.Done():
+			// the context is already cancelled, so a fresh one is used for the last flush
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			r.flush(flushCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// flush writes the collected counters to the store. If the store is not
+// available the counters are kept and retried with the next flush.
+func (r *recorder) flush(ctx context.Context) {
+	r.lock.Lock()
+	deltas := make(map[Pair]Counters, len(r.deltas))
+	for pair, counters := range r.deltas {
+		deltas[pair] = *counters
+	}
+	r.deltas = map[Pair]*Counters{}
+	r.lock.Unlock()
+
+	if len(deltas) == 0 {
+		return
+	}
+
+	if err := r.store.Increment(ctx, deltas); err != nil {
+		log.Warnf("failed to flush cache statistics, retrying with the next flush: %v", err)
+		r.lock.Lock()
+		for pair, delta := range deltas {
+			counters, ok := r.deltas[pair]
+			if !ok {
+				counters = &Counters{}
+				r.deltas[pair] = counters
+			}
+			counters.add(delta)
+		}
+		r.lock.Unlock()
+	}
+}
+
+// logSummary logs the aggregated counters of all replicas.
+func (r *recorder) logSummary(ctx context.Context) {
+	snapshot, err := r.Snapshot(ctx)
+	if err != nil {
+		log.Warnf("failed to load cache statistics: %v", err)
+		return
+	}
+
+	total := snapshot.Total
+	log.Infof("cache statistics: %d hits, %d misses (hit ratio %.1f%%), %d cache errors, %d upstream errors, %d characters saved, %d characters sent upstream, compression ratio %.2f",
+		total.Hits, total.Misses, total.HitRatio*100, total.CacheErrors, total.UpstreamErrors, total.CharsSaved, total.CharsUpstream, total.CompressionRatio)
+	for i, pair := range snapshot.Pairs {
+		// only the pairs with the highest savings are logged
+		if i == 5 {
+			break
+		}
+		log.Infof("cache statistics for %s -> %s: %d hits, %d misses (hit ratio %.1f%%), %d characters saved",
+			pair.SourceLang, pair.TargetLang, pair.Hits, pair.Misses, pair.HitRatio*100, pair.CharsSaved)
+	}
+}
@@ -0,0 +1,154 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+	fieldHits           = "hits"
+	fieldMisses         = "misses"
+	fieldCacheErrors    = "cache_errors"
+	fieldUpstreamErrors = "upstream_errors"
+	fieldCharsSaved     = "chars_saved"
+	fieldCharsUpstream  = "chars_upstream"
+	fieldBytesRaw       = "bytes_raw"
+	fieldBytesStored    = "bytes_stored"
+)
+
+type redisStore struct {
+	client    redis.UniversalClient
+	namespace string
+}
+
+// NewRedisStore creates a store that keeps the counters of each language pair
+// in a redis hash, so that they are aggregated across replicas.
+func NewRedisStore(client redis.UniversalClient, namespace string) Store {
+	return &redisStore{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Increment adds the deltas to the stored counters in a single pipeline.
+func (s *redisStore) Increment(ctx context.Context, deltas map[Pair]Counters) error {
+	pipe := s.client.Pipeline()
+	for pair, delta := range deltas {
+		key := s.pairKey(pair)
+		for field, value := range map[string]uint64{
+			fieldHits:           delta.Hits,
+			fieldMisses:         delta.Misses,
+			fieldCacheErrors:    delta.CacheErrors,
+			fieldUpstreamErrors: delta.UpstreamErrors,
+			fieldCharsSaved:     delta.CharsSaved,
+			fieldCharsUpstream:  delta.CharsUpstream,
+			fieldBytesRaw:       delta.BytesRaw,
+			fieldBytesStored:    delta.BytesStored,
+		} {
+			if value > 0 {
+				pipe.HIncrBy(ctx, key, field, int64(value))
+			}
+		}
+		pipe.SAdd(ctx, s.pairsKey(), pair.SourceLang+":"+pair.TargetLang)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Load returns the stored counters of all language pairs.
+func (s *redisStore) Load(ctx context.Context) (map[Pair]Counters, error) {
+	members, err := s.client.SMembers(ctx, s.pairsKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]Pair, 0, len(members))
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(members))
+	for _, member := range members {
+		sourceLang, targetLang, _ := strings.Cut(member, ":")
+		pair := Pair{
+			SourceLang: sourceLang,
+			TargetLang: targetLang,
+		}
+		pairs = append(pairs, pair)
+		cmds = append(cmds, pipe.HGetAll(ctx, s.pairKey(pair)))
+	}
+	if len(cmds) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	counters := make(map[Pair]Counters, len(pairs))
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		counters[pairs[i]] = Counters{
+			Hits:           parseCounter(fields[fieldHits]),
+			Misses:         parseCounter(fields[fieldMisses]),
+			CacheErrors:    parseCounter(fields[fieldCacheErrors]),
+			UpstreamErrors: parseCounter(fields[fieldUpstreamErrors]),
+			CharsSaved:     parseCounter(fields[fieldCharsSaved]),
+			CharsUpstream:  parseCounter(fields[fieldCharsUpstream]),
+			BytesRaw:       parseCounter(fields[fieldBytesRaw]),
+			BytesStored:    parseCounter(fields[fieldBytesStored]),
+		}
+	}
+	return counters, nil
+}
+
+func (s *redisStore) pairKey(pair Pair) string {
+	return fmt.Sprintf("%s:stats:%s:%s", s.namespace, pair.SourceLang, pair.TargetLang)
+}
+
+func (s *redisStore) pairsKey() string {
+	return fmt.Sprintf("%s:stats:pairs", s.namespace)
+}
+
+func parseCounter(value string) uint64 {
+	counter, _ := strconv.ParseUint(value, 10, 64)
+	return counter
+}
+
+type memoryStore struct {
+	counters map[Pair]Counters
+	lock     sync.Mutex
+}
+
+// NewMemoryStore creates a store that keeps the counters in memory for
+// deployments without redis.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		counters: map[Pair]Counters{},
+	}
+}
+
+// Increment adds the deltas to the stored counters.
+func (s *memoryStore) Increment(ctx context.Context, deltas map[Pair]Counters) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for pair, delta := range deltas {
+		counters := s.counters[pair]
+		counters.add(delta)
+		s.counters[pair] = counters
+	}
+	return nil
+}
+
+// Load returns the stored counters of all language pairs.
+func (s *memoryStore) Load(ctx context.Context) (map[Pair]Counters, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counters := make(map[Pair]Counters, len(s.counters))
+	for pair, c := range s.counters {
+		counters[pair] = c
+	}
+	return counters, nil
+}
@@ -10,11 +10,19 @@ import (
 type Language struct {
 	DisplayName string
 	IsoCode     string
+
+	// SupportsSource and SupportsTarget report whether the provider can
+	// translate from and into the language.
+	SupportsSource bool
+	SupportsTarget bool
 }
 
 type AvailableLanguages interface {
 	ByDisplayName(displayName string) Language
+	ByIsoCode(isoCode string) Language
 	DisplayNames() []string
+	Languages() []Language
+	Filter(keep func(lang Language) bool) AvailableLanguages
 }
 
 type availableLanguages struct {
@@ -26,8 +34,10 @@ func ParseAvailableLanguages(supportedLanguages []*translatepb.SupportedLanguage
 	languages := map[string]Language{}
 	for _, language := range supportedLanguages {
 		languages[strings.ToLower(language.DisplayName)] = Language{
-			DisplayName: language.GetDisplayName(),
-			IsoCode:     language.GetLanguageCode(),
+			DisplayName:    language.GetDisplayName(),
+			IsoCode:        language.GetLanguageCode(),
+			SupportsSource: language.GetSupportSource(),
+			SupportsTarget: language.GetSupportTarget(),
 		}
 	}
 	return &availableLanguages{
@@ -40,6 +50,16 @@ func (a *availableLanguages) ByDisplayName(displayName string) Language {
 	return a.languages[strings.ToLower(displayName)]
 }
 
+// ByIsoCode returns an available language by its iso code.
+func (a *availableLanguages) ByIsoCode(isoCode string) Language {
+	for _, lang := range a.languages {
+		if strings.EqualFold(lang.IsoCode, isoCode) {
+			return lang
+		}
+	}
+	return Language{}
+}
+
 // DisplayNames returns a list with the display names of all available languages.
 func (a *availableLanguages) DisplayNames() []string {
 	names := make([]string, 0, len(a.languages))
@@ -49,3 +69,28 @@ func (a *availableLanguages) DisplayNames() []string {
 	slices.Sort(names)
 	return names
 }
+
+// Languages returns all available languages sorted by their display name.
+func (a *availableLanguages) Languages() []Language {
+	languages := make([]Language, 0, len(a.languages))
+	for _, lang := range a.languages {
+		languages = append(languages, lang)
+	}
+	slices.SortFunc(languages, func(a, b Language) int {
+		return strings.Compare(a.DisplayName, b.DisplayName)
+	})
+	return languages
+}
+
+// Filter returns the available languages for which keep returns true.
+func (a *availableLanguages) Filter(keep func(lang Language) bool) AvailableLanguages {
+	languages := map[string]Language{}
+	for key, lang := range a.languages {
+		if keep(lang) {
+			languages[key] = lang
+		}
+	}
+	return &availableLanguages{
+		languages: languages,
+	}
+}
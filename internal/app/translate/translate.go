@@ -2,6 +2,7 @@ package translate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	translate "cloud.google.com/go/translate/apiv3"
@@ -11,18 +12,47 @@ import (
 
 var log = logger.NewLogger("app.translator")
 
+// ProviderGoogle identifies the Google Cloud Translation API.
+const ProviderGoogle = "google"
+
+// DefaultModel is the model used by the Google Cloud Translation API if no
+// model is specified.
+const DefaultModel = "general/nmt"
+
 type Options struct {
 	ProjectId string
+	Model     string
 }
 
 type Translator interface {
+	Provider() string
+	Model() string
 	AvailableLanguages() AvailableLanguages
-	Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*string, error)
+	Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*Translation, error)
+	DetectLanguage(ctx context.Context, input string) (*Detection, error)
 	Close()
 }
 
+// Translation is the translated text returned by the provider.
+type Translation struct {
+	Text string
+
+	// DetectedSourceLang is the source language detected by the provider if
+	// no source language has been requested.
+	DetectedSourceLang string
+}
+
+// Detection is the most likely language of a text detected by the provider.
+type Detection struct {
+	Language string
+
+	// Confidence is the confidence of the detection between 0 and 1.
+	Confidence float32
+}
+
 type translator struct {
 	projectId          string
+	model              string
 	client             *translate.TranslationClient
 	availableLanguages AvailableLanguages
 }
@@ -44,22 +74,40 @@ func NewTranslator(ctx context.Context, opts Options) Translator {
 		log.Fatalf("failed to load supported languages of cloud translation api: %v", err)
 	}
 
+	model := opts.Model
+	if model == "" {
+		model = DefaultModel
+	}
+
 	return &translator{
 		projectId:          opts.ProjectId,
+		model:              model,
 		client:             client,
 		availableLanguages: ParseAvailableLanguages(supLangRes.GetLanguages()),
 	}
 }
 
+// Provider returns the identifier of the translation provider.
+func (t *translator) Provider() string {
+	return ProviderGoogle
+}
+
+// Model returns the model that is used for translations.
+func (t *translator) Model() string {
+	return t.model
+}
+
 // AvailableLanguages returns an interface to interact with the available languages.
 func (t *translator) AvailableLanguages() AvailableLanguages {
 	return t.availableLanguages
 }
 
 // Translate returns a translation by requesting it at the Google Cloud Translate API.
-func (t *translator) Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*string, error) {
+func (t *translator) Translate(ctx context.Context, sourceLang string, targetLang string, input string) (*Translation, error) {
+	parent := fmt.Sprintf("projects/%s/locations/global", t.projectId)
 	req := &translatepb.TranslateTextRequest{
-		Parent:             fmt.Sprintf("projects/%s/locations/global", t.projectId),
+		Parent:             parent,
+		Model:              fmt.Sprintf("%s/models/%s", parent, t.model),
 		SourceLanguageCode: sourceLang,
 		TargetLanguageCode: targetLang,
 		MimeType:           "text/plain", // Mime types: "text/plain", "text/html"
@@ -69,7 +117,33 @@ func (t *translator) Translate(ctx context.Context, sourceLang string, targetLan
 	if err != nil {
 		return nil, err
 	}
-	return &resp.GetTranslations()[0].TranslatedText, nil
+	translation := resp.GetTranslations()[0]
+	return &Translation{
+		Text:               translation.GetTranslatedText(),
+		DetectedSourceLang: translation.GetDetectedLanguageCode(),
+	}, nil
+}
+
+// DetectLanguage returns the most likely language of the input by requesting it
+// at the Google Cloud Translate API.
+func (t *translator) DetectLanguage(ctx context.Context, input string) (*Detection, error) {
+	req := &translatepb.DetectLanguageRequest{
+		Parent:   fmt.Sprintf("projects/%s/locations/global", t.projectId),
+		Source:   &translatepb.DetectLanguageRequest_Content{Content: input},
+		MimeType: "text/plain",
+	}
+	resp, err := t.client.DetectLanguage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	languages := resp.GetLanguages()
+	if len(languages) == 0 {
+		return nil, errors.New("no language detected")
+	}
+	return &Detection{
+		Language:   languages[0].GetLanguageCode(),
+		Confidence: languages[0].GetConfidence(),
+	}, nil
 }
 
 // Close closes the API client.
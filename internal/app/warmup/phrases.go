@@ -0,0 +1,114 @@
+package warmup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// csvTextColumn is the name of the column containing the phrases in a CSV
+// file with header. Files without such a column use the first column.
+const csvTextColumn = "text"
+
+// readPhrases reads the phrases from a text, CSV or JSONL file, which is
+// detected by its extension. Empty and duplicate phrases are removed.
+func readPhrases(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var phrases []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		phrases, err = readCSV(file)
+	case ".jsonl", ".ndjson":
+		phrases, err = readJSONL(file)
+	default:
+		phrases, err = readText(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unique(phrases), nil
+}
+
+// readText reads one phrase per line. Lines starting with # are comments.
+func readText(r io.Reader) ([]string, error) {
+	var phrases []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(line, "#") {
+			phrases = append(phrases, line)
+		}
+	}
+	return phrases, scanner.Err()
+}
+
+// readCSV reads the phrases from the text column or the first column.
+func readCSV(r io.Reader) ([]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	column := 0
+	for i, name := range records[0] {
+		if strings.EqualFold(strings.TrimSpace(name), csvTextColumn) {
+			column = i
+			records = records[1:]
+			break
+		}
+	}
+
+	phrases := make([]string, 0, len(records))
+	for _, record := range records {
+		if column < len(record) {
+			phrases = append(phrases, record[column])
+		}
+	}
+	return phrases, nil
+}
+
+// readJSONL reads the phrases from the text field of each line.
+func readJSONL(r io.Reader) ([]string, error) {
+	var phrases []string
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var entry struct {
+			Text string `json:"text"`
+		}
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return phrases, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid phrase in entry %d: %w", line, err)
+		}
+		phrases = append(phrases, entry.Text)
+	}
+}
+
+func unique(phrases []string) []string {
+	seen := make(map[string]struct{}, len(phrases))
+	result := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		phrase = strings.TrimSpace(phrase)
+		if _, ok := seen[phrase]; ok || phrase == "" {
+			continue
+		}
+		seen[phrase] = struct{}{}
+		result = append(result, phrase)
+	}
+	return result
+}
@@ -0,0 +1,186 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dennishilgert/cloud-computing-2/internal/app/pipeline"
+	"github.com/dennishilgert/cloud-computing-2/pkg/logger"
+)
+
+var log = logger.NewLogger("app.warmup")
+
+// progressInterval defines how often the progress is logged.
+const progressInterval = 10 * time.Second
+
+type Options struct {
+	// File is the phrase file in text, CSV or JSONL format.
+	File string
+
+	// SourceLang is the language of the phrases. Empty lets the provider detect it.
+	SourceLang string
+
+	// TargetLangs are the languages the phrases are translated into.
+	TargetLangs []string
+
+	// Concurrency is the amount of translations running in parallel.
+	Concurrency int
+
+	// MaxChars limits the characters sent upstream. The limit is checked before
+	// each translation, so it may be exceeded by the translations in flight.
+	// Zero disables the limit.
+	MaxChars int
+}
+
+// Progress contains the progress of a warm-up.
+type Progress struct {
+	Total      int
+	Done       int
+	Cached     int
+	Translated int
+	Failed     int
+	Skipped    int
+	CharsSent  int
+}
+
+type Warmup interface {
+	// Warm translates all phrases into all target languages and returns once
+	// the warm-up is done or the context is cancelled.
+	Warm(ctx context.Context) (*Progress, error)
+
+	// Run warms the cache as a runner and blocks until the context is done.
+	Run(ctx context.Context) error
+}
+
+type warmup struct {
+	pipeline pipeline.Pipeline
+	opts     Options
+}
+
+func NewWarmup(pipeline pipeline.Pipeline, opts Options) Warmup {
+	return &warmup{
+		pipeline: pipeline,
+		opts:     opts,
+	}
+}
+
+type job struct {
+	phrase     string
+	targetLang string
+}
+
+// Warm translates all phrases into all target languages through the pipeline,
+// so that the translations are cached. Phrases already cached do not count
+// against the character budget.
+func (w *warmup) Warm(ctx context.Context) (*Progress, error) {
+	phrases, err := readPhrases(w.opts.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read phrases from %s: %w", w.opts.File, err)
+	}
+	if len(w.opts.TargetLangs) == 0 {
+		return nil, errors.New("no target languages configured")
+	}
+
+	total := len(phrases) * len(w.opts.TargetLangs)
+	log.Infof("warming up cache with %d phrases in %d languages", len(phrases), len(w.opts.TargetLangs))
+
+	var (
+		done, cached, translated, failed, skipped, charsSent atomic.Int64
+	)
+	progress := func() *Progress {
+		return &Progress{
+			Total:      total,
+			Done:       int(done.Load()),
+			Cached:     int(cached.Load()),
+			Translated: int(translated.Load()),
+			Failed:     int(failed.Load()),
+			Skipped:    int(skipped.Load()),
+			CharsSent:  int(charsSent.Load()),
+		}
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < max(w.opts.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				chars := utf8.RuneCountInString(j.phrase)
+				if w.opts.MaxChars > 0 && int(charsSent.Load())+chars > w.opts.MaxChars {
+					skipped.Add(1)
+					done.Add(1)
+					continue
+				}
+
+				result, err := w.pipeline.Translate(ctx, pipeline.Request{
+					Input:      j.phrase,
+					SourceLang: w.opts.SourceLang,
+					TargetLang: j.targetLang,
+				})
+				switch {
+				case err != nil:
+					log.Warnf("failed to warm up translation into %s: %v", j.targetLang, err)
+					failed.Add(1)
+				case result.Cached:
+					cached.Add(1)
+				default:
+					translated.Add(1)
+					charsSent.Add(int64(chars))
+				}
+				done.Add(1)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	// the jobs are produced in the current goroutine, so that the progress can
+	// be logged while waiting for the workers to accept the next job
+produce:
+	for _, targetLang := range w.opts.TargetLangs {
+		for _, phrase := range phrases {
+			j := job{
+				phrase:     phrase,
+				targetLang: targetLang,
+			}
+			for sent := false; !sent; {
+				select {
+				case jobs <- j:
+					sent = true
+				case <-ticker.C:
+					logProgress(progress())
+				case <-ctx.Done():
+					break produce
+				}
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := progress()
+	logProgress(result)
+	return result, ctx.Err()
+}
+
+// Run warms the cache and blocks until the context is done, since a returning
+// runner stops all other runners.
+func (w *warmup) Run(ctx context.Context) error {
+	if _, err := w.Warm(ctx); err != nil && ctx.Err() == nil {
+		log.Errorf("cache warm-up failed: %v", err)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func logProgress(p *Progress) {
+	log.Infof("cache warm-up progress: %d/%d done, %d already cached, %d translated, %d failed, %d skipped due to budget, %d characters sent upstream",
+		p.Done, p.Total, p.Cached, p.Translated, p.Failed, p.Skipped, p.CharsSent)
+}
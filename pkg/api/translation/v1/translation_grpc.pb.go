@@ -0,0 +1,265 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: translation/v1/translation.proto
+
+package translationv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	TranslationService_Translate_FullMethodName       = "/translation.v1.TranslationService/Translate"
+	TranslationService_TranslateStream_FullMethodName = "/translation.v1.TranslationService/TranslateStream"
+	TranslationService_ListLanguages_FullMethodName   = "/translation.v1.TranslationService/ListLanguages"
+	TranslationService_DetectLanguage_FullMethodName  = "/translation.v1.TranslationService/DetectLanguage"
+)
+
+// TranslationServiceClient is the client API for TranslationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TranslationServiceClient interface {
+	// Translate translates a single text.
+	Translate(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (*TranslateResponse, error)
+	// TranslateStream translates the texts of the stream in order and sends a
+	// response for each request. The stream ends with the first failed request.
+	TranslateStream(ctx context.Context, opts ...grpc.CallOption) (TranslationService_TranslateStreamClient, error)
+	// ListLanguages lists the languages permitted by the provider and the
+	// language policy.
+	ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*ListLanguagesResponse, error)
+	// DetectLanguage detects the language of a text.
+	DetectLanguage(ctx context.Context, in *DetectLanguageRequest, opts ...grpc.CallOption) (*DetectLanguageResponse, error)
+}
+
+type translationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTranslationServiceClient(cc grpc.ClientConnInterface) TranslationServiceClient {
+	return &translationServiceClient{cc}
+}
+
+func (c *translationServiceClient) Translate(ctx context.Context, in *TranslateRequest, opts ...grpc.CallOption) (*TranslateResponse, error) {
+	out := new(TranslateResponse)
+	err := c.cc.Invoke(ctx, TranslationService_Translate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *translationServiceClient) TranslateStream(ctx context.Context, opts ...grpc.CallOption) (TranslationService_TranslateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TranslationService_ServiceDesc.Streams[0], TranslationService_TranslateStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &translationServiceTranslateStreamClient{stream}
+	return x, nil
+}
+
+type TranslationService_TranslateStreamClient interface {
+	Send(*TranslateRequest) error
+	Recv() (*TranslateResponse, error)
+	grpc.ClientStream
+}
+
+type translationServiceTranslateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *translationServiceTranslateStreamClient) Send(m *TranslateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *translationServiceTranslateStreamClient) Recv() (*TranslateResponse, error) {
+	m := new(TranslateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *translationServiceClient) ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*ListLanguagesResponse, error) {
+	out := new(ListLanguagesResponse)
+	err := c.cc.Invoke(ctx, TranslationService_ListLanguages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *translationServiceClient) DetectLanguage(ctx context.Context, in *DetectLanguageRequest, opts ...grpc.CallOption) (*DetectLanguageResponse, error) {
+	out := new(DetectLanguageResponse)
+	err := c.cc.Invoke(ctx, TranslationService_DetectLanguage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TranslationServiceServer is the server API for TranslationService service.
+// All implementations must embed UnimplementedTranslationServiceServer
+// for forward compatibility
+type TranslationServiceServer interface {
+	// Translate translates a single text.
+	Translate(context.Context, *TranslateRequest) (*TranslateResponse, error)
+	// TranslateStream translates the texts of the stream in order and sends a
+	// response for each request. The stream ends with the first failed request.
+	TranslateStream(TranslationService_TranslateStreamServer) error
+	// ListLanguages lists the languages permitted by the provider and the
+	// language policy.
+	ListLanguages(context.Context, *ListLanguagesRequest) (*ListLanguagesResponse, error)
+	// DetectLanguage detects the language of a text.
+	DetectLanguage(context.Context, *DetectLanguageRequest) (*DetectLanguageResponse, error)
+	mustEmbedUnimplementedTranslationServiceServer()
+}
+
+// UnimplementedTranslationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTranslationServiceServer struct {
+}
+
+func (UnimplementedTranslationServiceServer) Translate(context.Context, *TranslateRequest) (*TranslateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Translate not implemented")
+}
+func (UnimplementedTranslationServiceServer) TranslateStream(TranslationService_TranslateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method TranslateStream not implemented")
+}
+func (UnimplementedTranslationServiceServer) ListLanguages(context.Context, *ListLanguagesRequest) (*ListLanguagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLanguages not implemented")
+}
+func (UnimplementedTranslationServiceServer) DetectLanguage(context.Context, *DetectLanguageRequest) (*DetectLanguageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DetectLanguage not implemented")
+}
+func (UnimplementedTranslationServiceServer) mustEmbedUnimplementedTranslationServiceServer() {}
+
+// UnsafeTranslationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TranslationServiceServer will
+// result in compilation errors.
+type UnsafeTranslationServiceServer interface {
+	mustEmbedUnimplementedTranslationServiceServer()
+}
+
+func RegisterTranslationServiceServer(s grpc.ServiceRegistrar, srv TranslationServiceServer) {
+	s.RegisterService(&TranslationService_ServiceDesc, srv)
+}
+
+func _TranslationService_Translate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TranslateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranslationServiceServer).Translate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranslationService_Translate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranslationServiceServer).Translate(ctx, req.(*TranslateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranslationService_TranslateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TranslationServiceServer).TranslateStream(&translationServiceTranslateStreamServer{stream})
+}
+
+type TranslationService_TranslateStreamServer interface {
+	Send(*TranslateResponse) error
+	Recv() (*TranslateRequest, error)
+	grpc.ServerStream
+}
+
+type translationServiceTranslateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *translationServiceTranslateStreamServer) Send(m *TranslateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *translationServiceTranslateStreamServer) Recv() (*TranslateRequest, error) {
+	m := new(TranslateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _TranslationService_ListLanguages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLanguagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranslationServiceServer).ListLanguages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranslationService_ListLanguages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranslationServiceServer).ListLanguages(ctx, req.(*ListLanguagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranslationService_DetectLanguage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DetectLanguageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranslationServiceServer).DetectLanguage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranslationService_DetectLanguage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranslationServiceServer).DetectLanguage(ctx, req.(*DetectLanguageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TranslationService_ServiceDesc is the grpc.ServiceDesc for TranslationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TranslationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "translation.v1.TranslationService",
+	HandlerType: (*TranslationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Translate",
+			Handler:    _TranslationService_Translate_Handler,
+		},
+		{
+			MethodName: "ListLanguages",
+			Handler:    _TranslationService_ListLanguages_Handler,
+		},
+		{
+			MethodName: "DetectLanguage",
+			Handler:    _TranslationService_DetectLanguage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TranslateStream",
+			Handler:       _TranslationService_TranslateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "translation/v1/translation.proto",
+}